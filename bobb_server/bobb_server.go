@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -34,6 +35,9 @@ var settings struct {
 	MaxErrs             int    `json:"maxErrs"`             // used if request ErrLimit is -1
 	KeySuffixWidth      int    `json:"keySuffixWidth"`      // width of zero-padded suffix for keys, see PutRequest.AddKeySuffix
 	DefaultKeyFld       string `json:"defaultKeyFld"`       // if request doesn't specify key field, this will be used
+	MaxSnapshots        int    `json:"maxSnapshots"`        // max # of open snapshots, see snapshot.go
+	MaxSnapshotSecs     int    `json:"maxSnapshotSecs"`     // max TTL of a snapshot
+	SnapshotEvictMs     int    `json:"snapshotEvictMs"`     // open snapshots are closed if an update runs longer
 }
 var db *bolt.DB
 var logFile *os.File
@@ -119,6 +123,7 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatal("Server forced to shutdown:", err)
 	}
+	bobb.CloseSnapshots() // release read trans held by snapshots
 	log.Println("Server Shutdown Complete")
 }
 
@@ -140,6 +145,9 @@ func process(op string, req bobb.Request, w http.ResponseWriter, r *http.Request
 	var response *bobb.Response
 	var err error
 
+	// records written as raw json instead of base64 if requested, see encoding.go
+	rawRecs := r.Header.Get(bobb.RecEncodingHeader) == bobb.RecEncodingRaw || r.URL.Query().Get(bobb.RecEncodingParam) == bobb.RecEncodingRaw
+
 	// NOTE - updt vs view requests
 	// updt(put) req cannot have refs to bolt values in response
 	//		writeResponse is executed outside bolt trans
@@ -148,21 +156,52 @@ func process(op string, req bobb.Request, w http.ResponseWriter, r *http.Request
 	// response refs to bolt values are valid only inside a bolt trans
 	// bolt allows concurrent View trans but not concurrent Update trans,
 	//    updt trans holds the database's exclusive write-lock for the entire duration of the network I/O
-	if req.IsUpdtReq() {
+	// snapshot requests run in a read trans held by the server, see snapshot.go
+	//		writeResponse is executed inside the snapshot trans
+	snapshotId := ""
+	if snapshotReq, ok := req.(bobb.SnapshotReader); ok {
+		snapshotId = snapshotReq.SnapshotId()
+	}
+	if dbReq, ok := req.(bobb.DBRequest); ok { // not run in a trans
+		response, err = dbReq.RunDB(db)
+		if err != nil {
+			log.Println("DB Error Occured -", op, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		} else {
+			writeResponse(response, w, rawRecs)
+		}
+	} else if snapshotId != "" {
+		err = bobb.ViewSnapshot(snapshotId, func(tx *bolt.Tx) error {
+			if response, err = req.Run(tx); err != nil {
+				return err
+			}
+			writeResponse(response, w, rawRecs) // executed inside snapshot trans
+			return nil
+		})
+		if err != nil { // response not written
+			msg := err.Error() + ", " + snapshotId
+			if err != bobb.ErrSnapshotNotFound {
+				log.Println("Snapshot Error Occured -", op, err)
+			}
+			writeResponse(&bobb.Response{Status: bobb.StatusFail, Msg: msg}, w, rawRecs)
+		}
+	} else if req.IsUpdtReq() {
+		stopWatch := bobb.WatchWriter() // closes snapshots if the update waits on them
 		db.Update(func(tx *bolt.Tx) error {
 			response, err = req.Run(tx)
 			return err
 		})
+		stopWatch()
 		if err != nil && err != bobb.ErrBadInputData {
 			log.Println("DB Error Occured - Update transaction rolled  back", err)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		} else {
-			writeResponse(response, w) // executed outside db transaction
+			writeResponse(response, w, rawRecs) // executed outside db transaction
 		}
 	} else {
 		db.View(func(tx *bolt.Tx) error {
-			response, err = req.Run(tx) // View requests always return nil err
-			writeResponse(response, w)  // executed inside db transaction
+			response, err = req.Run(tx)         // View requests always return nil err
+			writeResponse(response, w, rawRecs) // executed inside db transaction
 			return err
 		})
 	}
@@ -212,11 +251,33 @@ func loadSettings(pathPrefix string) {
 	bobb.InitialRespRecsSize = settings.InitialRespRecsSize
 	bobb.MaxErrs = settings.MaxErrs
 	bobb.KeySuffixWidth = settings.KeySuffixWidth
+
+	if settings.MaxSnapshots < 1 {
+		settings.MaxSnapshots = 10
+	}
+	if settings.MaxSnapshotSecs < 1 {
+		settings.MaxSnapshotSecs = 60
+	}
+	if settings.SnapshotEvictMs < 1 {
+		settings.SnapshotEvictMs = 1000
+	}
+	bobb.MaxSnapshots = settings.MaxSnapshots
+	bobb.MaxSnapshotSecs = settings.MaxSnapshotSecs
+	bobb.SnapshotEvictMs = settings.SnapshotEvictMs
 }
 
 // writeResponse returns response to client
-func writeResponse(resp *bobb.Response, w http.ResponseWriter) {
+func writeResponse(resp *bobb.Response, w http.ResponseWriter, rawRecs bool) {
 	w.Header().Set("Content-Type", "application/json")
+	encode := func(writer io.Writer) error {
+		return json.NewEncoder(writer).Encode(resp)
+	}
+	if rawRecs {
+		w.Header().Set(bobb.RecEncodingHeader, bobb.RecEncodingRaw)
+		encode = func(writer io.Writer) error {
+			return bobb.WriteRawResponse(writer, resp)
+		}
+	}
 
 	if settings.CompressResponse {
 		w.Header().Set("Content-Encoding", "gzip")
@@ -224,8 +285,7 @@ func writeResponse(resp *bobb.Response, w http.ResponseWriter) {
 		compressor := gzipWriterPool.Get().(*gzip.Writer)
 		compressor.Reset(w)
 
-		encoder := json.NewEncoder(compressor)
-		err := encoder.Encode(resp)
+		err := encode(compressor)
 		if err != nil {
 			log.Println("json encoding failed, with compression", err)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -237,8 +297,7 @@ func writeResponse(resp *bobb.Response, w http.ResponseWriter) {
 		}
 		gzipWriterPool.Put(compressor)
 	} else {
-		encoder := json.NewEncoder(w)
-		err := encoder.Encode(resp)
+		err := encode(w)
 		if err != nil {
 			log.Println("json encoding failed, no compression", err)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -27,10 +27,54 @@ func stdRoutes() {
 		var req bobb.GetAllKeysRequest
 		process(bobb.OpGetAllKeys, &req, w, r)
 	})
+	mux.HandleFunc("/multiget", func(w http.ResponseWriter, r *http.Request) {
+		var req bobb.MultiGetRequest
+		process(bobb.OpMultiGet, &req, w, r)
+	})
+	mux.HandleFunc("/snapshot", func(w http.ResponseWriter, r *http.Request) {
+		var req bobb.SnapshotRequest
+		process(bobb.OpSnapshot, &req, w, r)
+	})
+	mux.HandleFunc("/listkeys", func(w http.ResponseWriter, r *http.Request) {
+		var req bobb.ListKeysRequest
+		process(bobb.OpListKeys, &req, w, r)
+	})
 	mux.HandleFunc("/qry", func(w http.ResponseWriter, r *http.Request) {
 		var req bobb.QryRequest
 		process(bobb.OpQry, &req, w, r)
 	})
+	mux.HandleFunc("/getbyindex", func(w http.ResponseWriter, r *http.Request) {
+		var req bobb.GetByIndexRequest
+		process(bobb.OpGetByIndex, &req, w, r)
+	})
+	mux.HandleFunc("/indexstats", func(w http.ResponseWriter, r *http.Request) {
+		var req bobb.IndexStatsRequest
+		process(bobb.OpIndexStats, &req, w, r)
+	})
+	mux.HandleFunc("/textsearch", func(w http.ResponseWriter, r *http.Request) {
+		var req bobb.TextSearchRequest
+		process(bobb.OpTextSearch, &req, w, r)
+	})
+	mux.HandleFunc("/facet", func(w http.ResponseWriter, r *http.Request) {
+		var req bobb.FacetRequest
+		process(bobb.OpFacet, &req, w, r)
+	})
+	mux.HandleFunc("/runsavedquery", func(w http.ResponseWriter, r *http.Request) {
+		var req bobb.RunSavedQueryRequest
+		process(bobb.OpRunSavedQry, &req, w, r)
+	})
+	mux.HandleFunc("/qrystr", func(w http.ResponseWriter, r *http.Request) {
+		var req bobb.QryStrRequest
+		process(bobb.OpQryStr, &req, w, r)
+	})
+	mux.HandleFunc("/unionqry", func(w http.ResponseWriter, r *http.Request) {
+		var req bobb.UnionQryRequest
+		process(bobb.OpUnionQry, &req, w, r)
+	})
+	mux.HandleFunc("/sample", func(w http.ResponseWriter, r *http.Request) {
+		var req bobb.SampleRequest
+		process(bobb.OpSample, &req, w, r)
+	})
 	mux.HandleFunc("/verifyindex", func(w http.ResponseWriter, r *http.Request) {
 		var req bobb.VerifyIndexRequest
 		process(bobb.OpVerifyIndex, &req, w, r)
@@ -38,6 +82,11 @@ func stdRoutes() {
 
 	// *** UPDATE REQUEST ROUTING *****************************************************
 
+	mux.HandleFunc("/savedquery", func(w http.ResponseWriter, r *http.Request) { // update trans only for save and delete
+		var req bobb.SavedQueryRequest
+		process(bobb.OpSavedQuery, &req, w, r)
+	})
+
 	mux.HandleFunc("/put", func(w http.ResponseWriter, r *http.Request) {
 		var req bobb.PutRequest
 		process(bobb.OpPut, &req, w, r)
@@ -64,6 +113,10 @@ func stdRoutes() {
 		var req bobb.BktRequest
 		process(bobb.OpBkt, &req, w, r)
 	})
+	mux.HandleFunc("/bktadmin", func(w http.ResponseWriter, r *http.Request) {
+		var req bobb.BktAdminRequest
+		process(bobb.OpBktAdmin, &req, w, r)
+	})
 	mux.HandleFunc("/export", func(w http.ResponseWriter, r *http.Request) {
 		var req bobb.ExportRequest
 		process(bobb.OpExport, &req, w, r)
@@ -0,0 +1,292 @@
+package bobb
+
+/*
+ChildJoin embeds the related records of a child bkt in each primary record, as an array fld.
+Ex. an order with its items, a location with its requests.
+
+Child records are found by key prefix:
+  - without IndexBkt, child bkt keys beginning with the primary rec KeyFld value followed by Separator,
+    ex. order items with keys "orderid_itemno", Separator "_"
+  - with IndexBkt, index keys beginning with the KeyFld value encoded as the 1st KeyFld of the index
+    IndexSetting (see indexKeyPrefix), their values (child bkt keys) are used to get child recs,
+    ex. requests indexed on locationId
+
+Child Criteria, SortKeys, Limit and Fields apply to the child records only.
+CountFld is loaded with the # of children meeting Criteria (before Limit), so it can be used in
+QryRequest Criteria and SortKeys when the ChildJoin is in ChildJoinsBeforeFind.
+*/
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/valyala/fastjson"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ChildJoin defines a one to many join, see comments at top of file.
+type ChildJoin struct {
+	ChildBkt   string      // bkt containing child recs
+	IndexBkt   string      // optional, index on ChildBkt, see comments at top of file
+	KeyFld     string      // fld in primary rec, its value is the prefix of child (or index) keys
+	Separator  string      // optional, appended to KeyFld value, prevents "ord1" matching "ord10_1", not used with IndexBkt
+	ToFld      string      // fld in primary rec where array of child recs is loaded, optional if CountFld set
+	CountFld   string      // optional, fld in primary rec where # of children meeting Criteria is loaded
+	Criteria   []FindGroup // optional, children must meet Criteria
+	SortKeys   []SortKey   // optional, order of children, default key order
+	Limit      int         // optional, max # of children loaded into ToFld, 0 means all
+	Fields     []string    // optional, projection of child recs, see projection.go
+	UseDefault bool        // if KeyFld not found or null, load empty array and 0 count instead of error
+}
+
+// compiledChildJoin is a validated ChildJoin.
+type compiledChildJoin struct {
+	ChildJoin
+	keyPath  []string
+	criteria []FindGroup
+	sortKeys []SortKey
+	setting  *IndexSetting // nil if no IndexBkt
+}
+
+// compileChildJoins validates joins, loads defaults and index settings, and compiles criteria and sort keys.
+// Errs of Criteria subqueries are appended to subErrs, see resolveSubQrys.
+func compileChildJoins(tx *bolt.Tx, joins []ChildJoin, subErrs *[]BobbErr) ([]compiledChildJoin, error) {
+	compiled := make([]compiledChildJoin, len(joins))
+	for i, join := range joins {
+		if join.ChildBkt == "" || join.KeyFld == "" {
+			return nil, fmt.Errorf("ChildJoin missing ChildBkt or KeyFld")
+		}
+		if join.ToFld == "" && join.CountFld == "" {
+			return nil, fmt.Errorf("ChildJoin %s needs ToFld or CountFld", join.ChildBkt)
+		}
+		c := compiledChildJoin{ChildJoin: join, keyPath: strings.Split(join.KeyFld, ".")}
+		if join.IndexBkt != "" {
+			var err error
+			if c.setting, err = joinIndexSetting(tx, join.IndexBkt, join.ChildBkt); err != nil {
+				return nil, err
+			}
+		}
+		for j, group := range join.Criteria {
+			validated, err := validateFindConditions(group)
+			if err != nil {
+				return nil, fmt.Errorf("ChildJoin %s, Criteria group %d - %s", join.ChildBkt, j, err.Error())
+			}
+			c.criteria = append(c.criteria, validated)
+		}
+		var err error
+		if c.criteria, err = resolveSubQrys(tx, c.criteria, subErrs); err != nil {
+			return nil, fmt.Errorf("ChildJoin %s, Criteria - %s", join.ChildBkt, err.Error())
+		}
+		if c.sortKeys, err = validateSortKeys(join.SortKeys); err != nil {
+			return nil, fmt.Errorf("ChildJoin %s, SortKeys - %s", join.ChildBkt, err.Error())
+		}
+		if _, err = newProjection(join.Fields); err != nil {
+			return nil, fmt.Errorf("ChildJoin %s, Fields - %s", join.ChildBkt, err.Error())
+		}
+		compiled[i] = c
+	}
+	return compiled, nil
+}
+
+// joinIndexSetting returns the IndexSetting of indexBkt, checking it is a key index of dataBkt (if not empty).
+func joinIndexSetting(tx *bolt.Tx, indexBkt, dataBkt string) (*IndexSetting, error) {
+	setting, err := getIndexSetting(tx, indexBkt)
+	if err != nil {
+		return nil, err
+	}
+	if setting.IndexType == IndexTypeText || len(setting.KeyFlds) == 0 {
+		return nil, fmt.Errorf("join index %s must be a key index with KeyFlds", indexBkt)
+	}
+	if dataBkt != "" && setting.DataBkt != dataBkt {
+		return nil, fmt.Errorf("join index %s is an index of %s, not %s", indexBkt, setting.DataBkt, dataBkt)
+	}
+	return setting, nil
+}
+
+// indexKeyPrefix encodes val as the 1st KeyFld of setting, followed by FldSeparator if more key components follow.
+func indexKeyPrefix(setting *IndexSetting, val *fastjson.Value) (string, error) {
+	fld := setting.KeyFlds[0]
+	var strVal string
+	var intVal int
+	var err error
+	switch {
+	case fld.FldType == FldTypeInt && val.Type() == fastjson.TypeNumber:
+		intVal, err = val.Int()
+	case fld.FldType == FldTypeInt:
+		return "", fmt.Errorf("value %s is not an int, index %s fld %s", val, setting.IndexBkt, fld.FldName)
+	case val.Type() == fastjson.TypeString:
+		strVal = string(val.GetStringBytes())
+	default:
+		strVal = val.String() // number or bool as json text
+	}
+	if err != nil {
+		return "", err
+	}
+	prefix, err := FormatFldVal(fld, strVal, intVal)
+	if err != nil {
+		return "", err
+	}
+	if len(setting.KeyFlds) > 1 || setting.KeySuffixWidth > 0 {
+		prefix += setting.FldSeparator // more key components follow
+	}
+	return prefix, nil
+}
+
+// childLoader loads child joins into primary recs, 1 per qryScan.run (not shared by workers).
+type childLoader struct {
+	tx        *bolt.Tx
+	joins     []compiledChildJoin
+	projs     []*projection // per join, nil if no Fields
+	parser    *fastjson.Parser
+	arrParser *fastjson.Parser // parses the child arrays of 1 primary rec, values set in primary rec
+	arena     fastjson.Arena   // count values
+	children  []SortRec
+	sortBuf   []byte
+	buf       []byte
+}
+
+func newChildLoader(tx *bolt.Tx, joins []compiledChildJoin) *childLoader {
+	l := &childLoader{tx: tx, joins: joins, projs: make([]*projection, len(joins))}
+	for i, join := range joins {
+		l.projs[i], _ = newProjection(join.Fields) // validated by compileChildJoins
+	}
+	l.parser = parserPool.Get()
+	l.arrParser = parserPool.Get()
+	return l
+}
+
+func (l *childLoader) release() {
+	parserPool.Put(l.parser)
+	parserPool.Put(l.arrParser)
+}
+
+// load sets the ToFld and CountFld of each join in parsedRec, lookups is incremented for each child rec read.
+// Returned recBytes is parsedRec with children loaded.
+func (l *childLoader) load(parsedRec *fastjson.Value, lookups *int) (recBytes []byte, bErr *BobbErr) {
+	l.arena.Reset()
+	l.buf = append(l.buf[:0], '{') // child arrays of all joins, parsed once, {"0":[...],"1":[...]}
+	counts := make([]int, len(l.joins))
+	for i := range l.joins {
+		if i > 0 {
+			l.buf = append(l.buf, ',')
+		}
+		l.buf = fmt.Appendf(l.buf, `"%d":[`, i)
+		counts[i], bErr = l.loadChildren(parsedRec, i, lookups)
+		if bErr != nil {
+			return nil, bErr
+		}
+		l.buf = append(l.buf, ']')
+	}
+	l.buf = append(l.buf, '}')
+	arrays, err := l.arrParser.ParseBytes(l.buf)
+	if err != nil {
+		return nil, e(ErrJoinParse, "error parsing child recs - "+err.Error(), nil, nil)
+	}
+	for i := range l.joins {
+		join := &l.joins[i]
+		if join.ToFld != "" {
+			parsedRec.Set(join.ToFld, arrays.Get(fmt.Sprint(i)))
+		}
+		if join.CountFld != "" {
+			parsedRec.Set(join.CountFld, l.arena.NewNumberInt(counts[i]))
+		}
+	}
+	return parsedRec.MarshalTo(nil), nil
+}
+
+// loadChildren appends the children of join j to l.buf, comma separated, and returns the # meeting criteria.
+func (l *childLoader) loadChildren(parsedRec *fastjson.Value, j int, lookups *int) (int, *BobbErr) {
+	join := &l.joins[j]
+	childBkt := l.tx.Bucket([]byte(join.ChildBkt))
+	if childBkt == nil {
+		return 0, e(ErrJoinBkt, fmt.Sprintf("invalid child join bkt, %s", join.ChildBkt), nil, nil)
+	}
+	readBkt := childBkt
+	if join.IndexBkt != "" {
+		if readBkt = l.tx.Bucket([]byte(join.IndexBkt)); readBkt == nil {
+			return 0, e(ErrJoinBkt, fmt.Sprintf("invalid child join index bkt, %s", join.IndexBkt), nil, nil)
+		}
+	}
+	keyVal := parsedRec.Get(join.keyPath...)
+	if keyVal == nil || keyVal.Type() == fastjson.TypeNull {
+		if join.UseDefault {
+			return 0, nil
+		}
+		return 0, e(ErrJoinFld, fmt.Sprintf("invalid child join KeyFld, %s", join.KeyFld), nil, nil)
+	}
+	var prefix []byte
+	switch {
+	case join.setting != nil:
+		indexPrefix, err := indexKeyPrefix(join.setting, keyVal)
+		if err != nil {
+			return 0, e(ErrJoinFld, fmt.Sprintf("invalid child join KeyFld %s value - %s", join.KeyFld, err.Error()), nil, nil)
+		}
+		prefix = []byte(indexPrefix)
+	case keyVal.Type() == fastjson.TypeString:
+		prefix = append(prefix, keyVal.GetStringBytes()...)
+		prefix = append(prefix, join.Separator...)
+	default:
+		prefix = keyVal.MarshalTo(prefix) // number used as its json text
+		prefix = append(prefix, join.Separator...)
+	}
+
+	l.children = l.children[:0]
+	count := 0
+	csr := readBkt.Cursor()
+	for k, v := csr.Seek(prefix); k != nil && strings.HasPrefix(string(k), string(prefix)); k, v = csr.Next() {
+		if join.IndexBkt != "" {
+			if v = childBkt.Get(v); v == nil {
+				return 0, e(ErrIndexRef, fmt.Sprintf("child join index %s value not in bkt %s", join.IndexBkt, join.ChildBkt), k, nil)
+			}
+		}
+		*lookups++
+		child, err := l.parser.ParseBytes(v)
+		if err != nil {
+			return 0, e(ErrJoinParse, fmt.Sprintf("error parsing child rec, bkt %s", join.ChildBkt), k, v)
+		}
+		keep := len(join.criteria) == 0
+		var bErr *BobbErr
+		for _, findGroup := range join.criteria {
+			if keep, bErr = parsedRecFind(child, findGroup); bErr != nil || keep {
+				break
+			}
+		}
+		if bErr != nil {
+			bErr.Key, bErr.Val = k, v
+			return 0, bErr
+		}
+		if !keep {
+			continue
+		}
+		count++
+		if join.ToFld == "" || (len(join.sortKeys) == 0 && join.Limit > 0 && count > join.Limit) {
+			continue // only counting
+		}
+		var sortOn []byte
+		if len(join.sortKeys) > 0 {
+			if l.sortBuf, bErr = encodeSortKey(l.sortBuf[:0], child, join.sortKeys); bErr != nil {
+				bErr.Key, bErr.Val = k, v
+				return 0, bErr
+			}
+			sortOn = slices.Clone(l.sortBuf)
+		}
+		if l.projs[j] != nil {
+			v = l.projs[j].apply(child)
+		}
+		l.children = append(l.children, SortRec{SortOn: sortOn, Value: v, seq: count})
+	}
+	if len(join.sortKeys) > 0 {
+		qrySort(l.children)
+	}
+	if join.Limit > 0 && len(l.children) > join.Limit {
+		l.children = l.children[:join.Limit]
+	}
+	for i, child := range l.children {
+		if i > 0 {
+			l.buf = append(l.buf, ',')
+		}
+		l.buf = append(l.buf, child.Value...)
+	}
+	return count, nil
+}
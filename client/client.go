@@ -21,6 +21,7 @@ import (
 var (
 	BaseURL string // port must match value in settings file used by bobb_server
 	Debug   bool
+	RawRecs bool // if true, server sends recs as raw json instead of base64, Run decodes both, see bobb encoding.go
 )
 
 // A minimal, valid gzip string (empty content compressed)
@@ -70,6 +71,9 @@ func Run(httpClient *http.Client, op string, payload any) (*bobb.Response, error
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept-Encoding", "gzip")
+	if RawRecs {
+		req.Header.Add(bobb.RecEncodingHeader, bobb.RecEncodingRaw)
+	}
 
 	httpClient.Timeout = 30 * time.Second
 	httpResp, err := httpClient.Do(req)
@@ -117,19 +121,26 @@ func Run(httpClient *http.Client, op string, payload any) (*bobb.Response, error
 	}
 
 	var bobbResp bobb.Response
+	rawRecs := httpResp.Header.Get(bobb.RecEncodingHeader) == bobb.RecEncodingRaw
 
-	if Debug {
-		fmt.Println("--- DEBUG MODE ON > client receiving ---")
+	if Debug || rawRecs {
 		var resultBuffer bytes.Buffer
 		_, err = io.Copy(&resultBuffer, respReader)
 		if err != nil {
 			requestFailed = true
-			log.Println("Debug Mode, io.Copy from http respReader failed", err)
+			log.Println("io.Copy from http respReader failed", err)
 			return nil, err
 		}
 		result := resultBuffer.Bytes()
-		fmt.Println(fmtJSON(result))
-		err = json.Unmarshal(result, &bobbResp)
+		if Debug {
+			fmt.Println("--- DEBUG MODE ON > client receiving ---")
+			fmt.Println(fmtJSON(result))
+		}
+		if rawRecs {
+			err = bobb.UnmarshalRawResponse(result, &bobbResp)
+		} else {
+			err = json.Unmarshal(result, &bobbResp)
+		}
 	} else {
 		err = json.NewDecoder(respReader).Decode(&bobbResp)
 	}
@@ -118,6 +118,12 @@ func GetOne(httpClient *http.Client, bkt, id string, result any) error {
 	return nil
 }
 
+// QryStr is shortcut func. Runs a text query, ex. "from location where st = 'TN' order by city limit 50".
+// See bobb requests_qrystr.go for the query syntax.
+func QryStr(httpClient *http.Client, qry string) (*bobb.Response, error) {
+	return Run(httpClient, bobb.OpQryStr, bobb.QryStrRequest{Qry: qry})
+}
+
 func CreateBkt(httpClient *http.Client, bktName string) error {
 	req := bobb.BktRequest{
 		BktName:   bktName,
@@ -17,8 +17,10 @@ const (
 	OpBkt          = "bkt"
 	OpGet          = "get"
 	OpGetOne       = "getone"
+	OpMultiGet     = "multiget"
 	OpGetAll       = "getall"
 	OpGetAllKeys   = "getallkeys"
+	OpListKeys     = "listkeys"
 	OpQry          = "qry"
 	OpPut          = "put"
 	OpPutIndex     = "putindex"
@@ -29,6 +31,17 @@ const (
 	OpExport       = "export"
 	OpClose        = "close"
 	OpCopyDB       = "copydb"
+	OpBktAdmin     = "bktadmin"
+	OpGetByIndex   = "getbyindex"
+	OpIndexStats   = "indexstats"
+	OpTextSearch   = "textsearch"
+	OpFacet        = "facet"
+	OpSavedQuery   = "savedquery"
+	OpRunSavedQry  = "runsavedquery"
+	OpQryStr       = "qrystr"
+	OpUnionQry     = "unionqry"
+	OpSample       = "sample"
+	OpSnapshot     = "snapshot"
 )
 
 // Response Status Values
@@ -63,6 +76,8 @@ const (
 	FindBefore       = "before"       // str - less than compare value
 	FindAfter        = "after"        // str - greater than compare value
 	FindInStrList    = "instrlist"    // str - in list
+	FindRegex        = "regex"        // str - matches regular expression (RE2 syntax)
+	FindFuzzy        = "fuzzy"        // str - edit distance <= MaxDist
 
 	FindLessThan    = "lessthan"    // int
 	FindGreaterThan = "greaterthan" // int
@@ -75,7 +90,7 @@ const (
 	FindNot = true // used to set FindCondition.Not field
 )
 
-var StrFindOps = []string{FindContains, FindContainsWord, FindMatches, FindStartsWith, FindEndsWith, FindBefore, FindAfter, FindInStrList}
+var StrFindOps = []string{FindContains, FindContainsWord, FindMatches, FindStartsWith, FindEndsWith, FindBefore, FindAfter, FindInStrList, FindRegex, FindFuzzy}
 var IntFindOps = []string{FindLessThan, FindGreaterThan, FindEquals, FindInIntList}
 var AllFindOps = slices.Concat(StrFindOps, IntFindOps, []string{FindExists, FindIsNull})
 
@@ -92,12 +107,33 @@ const (
 	ErrJoinKey     = "joinkey"     // join key not found in join bkt
 	ErrJoinParse   = "joinparse"   // error parsing join record
 	ErrJoinFromFld = "joinfromfld" // join from fld invalid
+	// Bkt Admin Errors
+	ErrBktNotFound  = "bktnotfound"  // bkt not found, operation skipped
+	ErrIndexSetting = "indexsetting" // index setting not found, operation skipped
+	ErrExpr         = "expr"         // error evaluating expression, see expr.go
 	// Verify Index Errors
 	ErrInvalidIndexValue   = "invalidindexvalue"   //
 	ErrDuplicateIndexValue = "duplicateindexvalue" //
 	ErrDataKeyNotIndexed   = "datakeynotindexed"   //
 )
 
+// IndexSetting IndexType Codes
+const (
+	IndexTypeKey  = "key"  // index key composed from KeyFlds (default)
+	IndexTypeText = "text" // index keys are words from TextFlds, see requests_text.go
+)
+
+var AllIndexTypes = []string{IndexTypeKey, IndexTypeText}
+
+// IndexCondition Op Codes, used by GetByIndexRequest
+const (
+	IndexEquals = "equals" // key fld value matches ValStr/ValInt (default)
+	IndexRange  = "range"  // key fld value between ValStr/ValInt and ToStr/ToInt, inclusive
+	IndexPrefix = "prefix" // key fld value begins with ValStr, string key flds only
+)
+
+var AllIndexOps = []string{IndexEquals, IndexRange, IndexPrefix}
+
 // UseDefault Codes, controls value returned when record field not found or is null
 // Default (zero value) is "" for string and 0 for int
 const (
@@ -131,6 +167,23 @@ const (
 	FldTypeInt = "int"
 )
 
+// FldFormat Transform Codes, derive index key component from string fld value
+const (
+	TransformDay    = "day"    // date or timestamp truncated to day, yyyy-mm-dd
+	TransformMonth  = "month"  // date or timestamp truncated to month, yyyy-mm
+	TransformPrefix = "prefix" // first PrefixLen chars, after StrOption conversion
+)
+
+var AllTransforms = []string{TransformDay, TransformMonth, TransformPrefix}
+
+// FacetFld SortBy Codes (FacetSortCount default)
+const (
+	FacetSortCount = "count" // highest count first, ties in value order
+	FacetSortValue = "value" // value order, numbers compared as numbers
+)
+
+var AllFacetSorts = []string{FacetSortCount, FacetSortValue}
+
 // PutRequest IndexingOption Codes (IndexingNormal default)
 const (
 	IndexingNormal   = "normal"   // adds and updates to index bkts (most processing)
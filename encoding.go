@@ -0,0 +1,153 @@
+package bobb
+
+/*
+Response record encoding.
+
+By default Response.Recs and Response.Rec are encoded by encoding/json as base64 strings.
+With RecEncodingRaw, negotiated by request header RecEncodingHeader or url query param RecEncodingParam,
+records are embedded in the response as json values, written straight from the bolt value:
+
+	{"Recs":[{"id":"001","city":"Austin"},{"id":"002","city":"Denver"}],"Rec":null,"Status":"ok",...}
+
+A rec that is a valid json object or array is written as is. Any other rec is written as a json string,
+so every rec decodes back to its exact bytes. Not every bolt value is json, ex. index bkt values are data keys
+(PutIndexRequest, Indexr), so a rec starting with '{' or '[' is validated, other recs are not scanned.
+Keys and bkt names (GetAllKeysRequest, BktRequest list, BktAdminRequest) are always written as json strings.
+The server sets RecEncodingHeader in the response when RecEncodingRaw is used.
+client.Run decodes both formats, see UnmarshalRawResponse. Other Response flds are encoded the same in both formats.
+
+	curl -s -H "Bobb-Rec-Encoding: raw" -d '{"BktName":"location","Keys":["001"]}' localhost:50555/get
+	curl -s -d '{"BktName":"location","Keys":["001"]}' "localhost:50555/get?recenc=raw"
+*/
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/valyala/fastjson"
+)
+
+const (
+	RecEncodingHeader = "Bobb-Rec-Encoding" // request and response header
+	RecEncodingParam  = "recenc"            // request url query param, alternative to header
+	RecEncodingRaw    = "raw"               // value of header or param
+)
+
+// rawResponse is a Response with Recs and Rec removed, they are written separately by WriteRawResponse.
+type rawResponse struct {
+	*Response
+	Recs *struct{} `json:",omitempty"` // nil, hides Response.Recs
+	Rec  *struct{} `json:",omitempty"` // nil, hides Response.Rec
+}
+
+// WriteRawResponse writes resp as json to w with Recs and Rec as raw json values, see comments at top of file.
+func WriteRawResponse(w io.Writer, resp *Response) error {
+	rest, err := json.Marshal(rawResponse{Response: resp}) // {"Status":...}
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 0, 64)
+	buf = append(buf, `{"Recs":`...)
+	if resp.Recs == nil {
+		buf = append(buf, "null"...)
+	} else {
+		buf = append(buf, '[')
+		for i, rec := range resp.Recs {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			if rec == nil { // ex. MultiGetRequest item not found
+				buf = append(buf, "null"...)
+				continue
+			}
+			if !resp.keyRecs && isRawRec(rec) { // written from bolt value, not copied to buf
+				if _, err = w.Write(buf); err != nil {
+					return err
+				}
+				if _, err = w.Write(rec); err != nil {
+					return err
+				}
+				buf = buf[:0]
+				continue
+			}
+			buf = appendStrRec(buf, rec)
+		}
+		buf = append(buf, ']')
+	}
+	buf = append(buf, `,"Rec":`...)
+	switch {
+	case resp.Rec == nil:
+		buf = append(buf, "null"...)
+	case isRawRec(resp.Rec):
+		buf = append(buf, resp.Rec...)
+	default:
+		buf = appendStrRec(buf, resp.Rec)
+	}
+	buf = append(buf, ',')
+	buf = append(buf, rest[1:]...)
+	buf = append(buf, '\n')
+	_, err = w.Write(buf)
+	return err
+}
+
+// isRawRec returns true if rec is a valid json object or array, which is written as is.
+// The 1st non space byte is checked before validating, so most non json recs are not scanned.
+func isRawRec(rec []byte) bool {
+	for _, c := range rec {
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case '{', '[':
+			return fastjson.ValidateBytes(rec) == nil // no allocations
+		}
+		return false
+	}
+	return false
+}
+
+// appendStrRec appends rec to buf as a json string.
+func appendStrRec(buf, rec []byte) []byte {
+	s, _ := json.Marshal(string(rec)) // string marshal does not fail
+	return append(buf, s...)
+}
+
+// UnmarshalRawResponse loads resp from data written by WriteRawResponse.
+// Json string recs are loaded as the string bytes, other recs as their json text.
+func UnmarshalRawResponse(data []byte, resp *Response) error {
+	var raw struct {
+		*Response
+		Recs []json.RawMessage
+		Rec  json.RawMessage
+	}
+	raw.Response = resp
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	if raw.Recs != nil {
+		resp.Recs = make([][]byte, len(raw.Recs))
+		for i, rec := range raw.Recs {
+			if resp.Recs[i], err = rawRecBytes(rec); err != nil {
+				return err
+			}
+		}
+	}
+	resp.Rec, err = rawRecBytes(raw.Rec)
+	return err
+}
+
+// rawRecBytes returns the rec bytes of 1 raw rec, nil if null.
+func rawRecBytes(rec json.RawMessage) ([]byte, error) {
+	switch {
+	case len(rec) == 0 || string(rec) == "null":
+		return nil, nil
+	case rec[0] == '"':
+		var s string
+		if err := json.Unmarshal(rec, &s); err != nil {
+			return nil, err
+		}
+		return []byte(s), nil
+	default:
+		return rec, nil // RawMessage is a copy
+	}
+}
@@ -0,0 +1,545 @@
+// The expr.go file contains a small expression language used by QryRequest.
+// Expressions can be used in place of a fld name (FindCondition.Expr, SortKey.Expr),
+// in place of a compare value (FindCondition.ValExpr), and to add computed flds to results (QryRequest.Computed).
+//
+// Operands:  fld names (ex. qty, agent.name for nested flds), numbers (ex. 10, 2.5), strings ('abc' or "abc"), null
+// Operators: * / % + -  (numbers), + concatenates when either operand is a string, unary -
+// Functions: len(s), lower(s), upper(s), date(s) or date(s, layout) returns unix seconds
+// Parentheses control order of evaluation, ex. (qty + 1) * price
+//
+// Fld not found or null is a null value. In arithmetic null is 0, in concatenation null is "".
+// Expressions are parsed once per request, errors are request validation failures.
+// Errors during evaluation (ex. 'abc' * 2) are record errors added to resp.Errs.
+
+package bobb
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/valyala/fastjson"
+)
+
+// expr value kinds
+const (
+	exprNull = iota
+	exprInt
+	exprFloat
+	exprStr
+)
+
+// exprVal is the result of evaluating an expression.
+type exprVal struct {
+	kind int
+	i    int
+	f    float64
+	s    string
+}
+
+func (v exprVal) str() string {
+	switch v.kind {
+	case exprInt:
+		return strconv.Itoa(v.i)
+	case exprFloat:
+		return strconv.FormatFloat(v.f, 'f', -1, 64)
+	case exprStr:
+		return v.s
+	}
+	return ""
+}
+
+func (v exprVal) num() (float64, error) {
+	switch v.kind {
+	case exprInt:
+		return float64(v.i), nil
+	case exprFloat:
+		return v.f, nil
+	case exprNull:
+		return 0, nil
+	}
+	return 0, fmt.Errorf("string value %q used as number", v.s)
+}
+
+// exprNode is a parsed expression element.
+type exprNode interface {
+	eval(parsedRec *fastjson.Value) (exprVal, error)
+}
+
+type exprFld struct {
+	path []string // fld name split on "."
+}
+
+type exprLit struct {
+	val exprVal
+}
+
+type exprNeg struct {
+	x exprNode
+}
+
+type exprBinary struct {
+	op   byte
+	x, y exprNode
+}
+
+type exprCall struct {
+	fn   string
+	args []exprNode
+}
+
+// expr is a parsed expression, created by parseExpr.
+type expr struct {
+	src  string
+	root exprNode
+}
+
+func (x *expr) eval(parsedRec *fastjson.Value) (exprVal, error) {
+	val, err := x.root.eval(parsedRec)
+	if err != nil {
+		return val, fmt.Errorf("expr %q: %s", x.src, err.Error())
+	}
+	return val, nil
+}
+
+func (n exprFld) eval(parsedRec *fastjson.Value) (exprVal, error) {
+	val := parsedRec.Get(n.path...)
+	if val == nil {
+		return exprVal{}, nil
+	}
+	switch val.Type() {
+	case fastjson.TypeNull:
+		return exprVal{}, nil
+	case fastjson.TypeString:
+		return exprVal{kind: exprStr, s: string(val.GetStringBytes())}, nil
+	case fastjson.TypeNumber:
+		if i, err := val.Int(); err == nil {
+			return exprVal{kind: exprInt, i: i}, nil
+		}
+		return exprVal{kind: exprFloat, f: val.GetFloat64()}, nil
+	case fastjson.TypeTrue:
+		return exprVal{kind: exprInt, i: 1}, nil
+	case fastjson.TypeFalse:
+		return exprVal{kind: exprInt, i: 0}, nil
+	}
+	return exprVal{}, fmt.Errorf("fld %s is not a string, number or bool", strings.Join(n.path, "."))
+}
+
+func (n exprLit) eval(parsedRec *fastjson.Value) (exprVal, error) {
+	return n.val, nil
+}
+
+func (n exprNeg) eval(parsedRec *fastjson.Value) (exprVal, error) {
+	x, err := n.x.eval(parsedRec)
+	if err != nil {
+		return x, err
+	}
+	switch x.kind {
+	case exprInt:
+		return exprVal{kind: exprInt, i: -x.i}, nil
+	case exprFloat:
+		return exprVal{kind: exprFloat, f: -x.f}, nil
+	case exprNull:
+		return exprVal{kind: exprInt}, nil
+	}
+	return exprVal{}, fmt.Errorf("cannot negate string %q", x.s)
+}
+
+func (n exprBinary) eval(parsedRec *fastjson.Value) (exprVal, error) {
+	x, err := n.x.eval(parsedRec)
+	if err != nil {
+		return x, err
+	}
+	y, err := n.y.eval(parsedRec)
+	if err != nil {
+		return y, err
+	}
+	if n.op == '+' && (x.kind == exprStr || y.kind == exprStr) {
+		return exprVal{kind: exprStr, s: x.str() + y.str()}, nil
+	}
+	if x.kind != exprFloat && y.kind != exprFloat { // int arithmetic, null is 0
+		if x.kind == exprStr || y.kind == exprStr {
+			return exprVal{}, fmt.Errorf("string used in %c operation", n.op)
+		}
+		a, b := x.i, y.i
+		switch n.op {
+		case '+':
+			return exprVal{kind: exprInt, i: a + b}, nil
+		case '-':
+			return exprVal{kind: exprInt, i: a - b}, nil
+		case '*':
+			return exprVal{kind: exprInt, i: a * b}, nil
+		case '/', '%':
+			if b == 0 {
+				return exprVal{}, fmt.Errorf("division by zero")
+			}
+			if n.op == '%' {
+				return exprVal{kind: exprInt, i: a % b}, nil
+			}
+			if a%b != 0 { // keep fraction, ex. 7 / 2 = 3.5
+				return exprVal{kind: exprFloat, f: float64(a) / float64(b)}, nil
+			}
+			return exprVal{kind: exprInt, i: a / b}, nil
+		}
+	}
+	a, err := x.num()
+	if err != nil {
+		return exprVal{}, err
+	}
+	b, err := y.num()
+	if err != nil {
+		return exprVal{}, err
+	}
+	switch n.op {
+	case '+':
+		return exprVal{kind: exprFloat, f: a + b}, nil
+	case '-':
+		return exprVal{kind: exprFloat, f: a - b}, nil
+	case '*':
+		return exprVal{kind: exprFloat, f: a * b}, nil
+	case '/':
+		if b == 0 {
+			return exprVal{}, fmt.Errorf("division by zero")
+		}
+		return exprVal{kind: exprFloat, f: a / b}, nil
+	case '%':
+		if b == 0 {
+			return exprVal{}, fmt.Errorf("division by zero")
+		}
+		return exprVal{kind: exprFloat, f: math.Mod(a, b)}, nil
+	}
+	return exprVal{}, fmt.Errorf("invalid operator %c", n.op)
+}
+
+// exprFuncArgs is the # of args allowed for each function, min and max.
+var exprFuncArgs = map[string][2]int{
+	"len":   {1, 1},
+	"lower": {1, 1},
+	"upper": {1, 1},
+	"date":  {1, 2},
+}
+
+func (n exprCall) eval(parsedRec *fastjson.Value) (exprVal, error) {
+	args := make([]exprVal, len(n.args))
+	for i, arg := range n.args {
+		val, err := arg.eval(parsedRec)
+		if err != nil {
+			return val, err
+		}
+		args[i] = val
+	}
+	switch n.fn {
+	case "len":
+		return exprVal{kind: exprInt, i: utf8.RuneCountInString(args[0].str())}, nil
+	case "lower":
+		return exprVal{kind: exprStr, s: strings.ToLower(args[0].str())}, nil
+	case "upper":
+		return exprVal{kind: exprStr, s: strings.ToUpper(args[0].str())}, nil
+	case "date":
+		if args[0].kind == exprNull || args[0].str() == "" {
+			return exprVal{}, nil
+		}
+		layouts := dateLayouts // see rec.go
+		if len(args) > 1 {
+			layouts = []string{args[1].str()}
+		}
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, args[0].str()); err == nil {
+				return exprVal{kind: exprInt, i: int(t.Unix())}, nil
+			}
+		}
+		return exprVal{}, fmt.Errorf("date(%q) invalid date", args[0].str())
+	}
+	return exprVal{}, fmt.Errorf("invalid function %s", n.fn)
+}
+
+// -- parser -------------------------------------------------------------
+
+// exprParser is a recursive descent parser, grammar:
+//
+//	sum     := product (("+" | "-") product)*
+//	product := unary (("*" | "/" | "%") unary)*
+//	unary   := "-" unary | primary
+//	primary := number | string | "null" | name "(" args ")" | name | "(" sum ")"
+type exprParser struct {
+	src string
+	pos int
+}
+
+// parseExpr parses src and returns the expression, or an error describing the syntax problem and its position.
+func parseExpr(src string) (*expr, error) {
+	p := &exprParser{src: src}
+	root, err := p.sum()
+	if err == nil {
+		p.skipSpace()
+		if p.pos < len(p.src) {
+			err = p.errorf("unexpected %q", p.src[p.pos:])
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &expr{src: src, root: root}, nil
+}
+
+func (p *exprParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("expr %q, pos %d: %s", p.src, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t' || p.src[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+// accept skips spaces and returns true, advancing pos, if next char is c.
+func (p *exprParser) accept(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) sum() (exprNode, error) {
+	x, err := p.product()
+	for err == nil {
+		var op byte
+		switch {
+		case p.accept('+'):
+			op = '+'
+		case p.accept('-'):
+			op = '-'
+		default:
+			return x, nil
+		}
+		var y exprNode
+		if y, err = p.product(); err == nil {
+			x = exprBinary{op: op, x: x, y: y}
+		}
+	}
+	return nil, err
+}
+
+func (p *exprParser) product() (exprNode, error) {
+	x, err := p.unary()
+	for err == nil {
+		var op byte
+		switch {
+		case p.accept('*'):
+			op = '*'
+		case p.accept('/'):
+			op = '/'
+		case p.accept('%'):
+			op = '%'
+		default:
+			return x, nil
+		}
+		var y exprNode
+		if y, err = p.unary(); err == nil {
+			x = exprBinary{op: op, x: x, y: y}
+		}
+	}
+	return nil, err
+}
+
+func (p *exprParser) unary() (exprNode, error) {
+	if p.accept('-') {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return exprNeg{x: x}, nil
+	}
+	return p.primary()
+}
+
+func (p *exprParser) primary() (exprNode, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("unexpected end of expression")
+	}
+	c := p.src[p.pos]
+	switch {
+	case c == '(':
+		p.pos++
+		x, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(')') {
+			return nil, p.errorf("missing )")
+		}
+		return x, nil
+	case c == '\'' || c == '"':
+		return p.str(c)
+	case c >= '0' && c <= '9' || c == '.':
+		return p.number()
+	case c == '_' || unicode.IsLetter(rune(c)):
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || p.src[p.pos] == '.' || unicode.IsLetter(rune(p.src[p.pos])) || unicode.IsDigit(rune(p.src[p.pos]))) {
+			p.pos++
+		}
+		name := p.src[start:p.pos]
+		if name == "null" {
+			return exprLit{}, nil
+		}
+		if p.accept('(') {
+			return p.call(name)
+		}
+		return exprFld{path: strings.Split(name, ".")}, nil
+	}
+	return nil, p.errorf("unexpected %q", string(c))
+}
+
+func (p *exprParser) call(fn string) (exprNode, error) {
+	argCount, found := exprFuncArgs[fn]
+	if !found {
+		return nil, p.errorf("unknown function %s", fn)
+	}
+	call := exprCall{fn: fn}
+	if !p.accept(')') {
+		for {
+			arg, err := p.sum()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if p.accept(')') {
+				break
+			}
+			if !p.accept(',') {
+				return nil, p.errorf("expected , or ) in %s args", fn)
+			}
+		}
+	}
+	if len(call.args) < argCount[0] || len(call.args) > argCount[1] {
+		return nil, p.errorf("function %s has wrong # of args, %d", fn, len(call.args))
+	}
+	return call, nil
+}
+
+func (p *exprParser) str(quote byte) (exprNode, error) {
+	p.pos++ // opening quote
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		p.pos++
+		switch {
+		case c == '\\' && p.pos < len(p.src):
+			sb.WriteByte(p.src[p.pos])
+			p.pos++
+		case c == quote:
+			return exprLit{val: exprVal{kind: exprStr, s: sb.String()}}, nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return nil, p.errorf("missing closing quote")
+}
+
+func (p *exprParser) number() (exprNode, error) {
+	start := p.pos
+	for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '.') {
+		p.pos++
+	}
+	numStr := p.src[start:p.pos]
+	if i, err := strconv.Atoi(numStr); err == nil {
+		return exprLit{val: exprVal{kind: exprInt, i: i}}, nil
+	}
+	f, err := strconv.ParseFloat(numStr, 64)
+	if err != nil {
+		return nil, p.errorf("invalid number %s", numStr)
+	}
+	return exprLit{val: exprVal{kind: exprFloat, f: f}}, nil
+}
+
+// -- used by QryRequest ---------------------------------------------------
+
+// ComputedFld adds a fld to each QryRequest result record with the value of an expression.
+type ComputedFld struct {
+	Fld  string // name of fld added to record, replaced if it already exists
+	Expr string // expression, see top of expr.go
+}
+
+// compiledFld is a ComputedFld with parsed expression.
+type compiledFld struct {
+	fld  string
+	expr *expr
+}
+
+// compileComputedFlds parses the expressions of computed flds.
+func compileComputedFlds(flds []ComputedFld) ([]compiledFld, error) {
+	compiled := make([]compiledFld, len(flds))
+	for i, fld := range flds {
+		if fld.Fld == "" {
+			return nil, fmt.Errorf("ComputedFld missing Fld")
+		}
+		x, err := parseExpr(fld.Expr)
+		if err != nil {
+			return nil, err
+		}
+		compiled[i] = compiledFld{fld: fld.Fld, expr: x}
+	}
+	return compiled, nil
+}
+
+// loadComputedValues adds computed fld values to parsedRec and returns the updated record.
+// Arena is reset and used to create the fastjson values.
+func loadComputedValues(parsedRec *fastjson.Value, flds []compiledFld, arena *fastjson.Arena) (recBytes []byte, bErr *BobbErr) {
+	arena.Reset()
+	for _, fld := range flds {
+		val, err := fld.expr.eval(parsedRec)
+		if err != nil {
+			bErr = e(ErrExpr, err.Error(), nil, nil)
+			return
+		}
+		var jsonVal *fastjson.Value
+		switch val.kind {
+		case exprInt:
+			jsonVal = arena.NewNumberInt(val.i)
+		case exprFloat:
+			jsonVal = arena.NewNumberFloat64(val.f)
+		case exprStr:
+			jsonVal = arena.NewString(val.s)
+		default:
+			jsonVal = arena.NewNull()
+		}
+		parsedRec.Set(fld.fld, jsonVal)
+	}
+	recBytes = parsedRec.MarshalTo(nil)
+	return
+}
+
+// exprGetStr evaluates x and returns the result as a string converted using option (see Str* codes).
+func exprGetStr(parsedRec *fastjson.Value, x *expr, option string) (string, *BobbErr) {
+	val, err := x.eval(parsedRec)
+	if err != nil {
+		return "", e(ErrExpr, err.Error(), nil, nil)
+	}
+	switch option {
+	case StrLowerCase:
+		return strings.ToLower(val.str()), nil
+	case StrPlain:
+		return PlainString(val.str()), nil
+	}
+	return val.str(), nil
+}
+
+// exprGetNum evaluates x and returns the numeric result.
+func exprGetNum(parsedRec *fastjson.Value, x *expr) (float64, *BobbErr) {
+	val, err := x.eval(parsedRec)
+	if err == nil {
+		var num float64
+		if num, err = val.num(); err == nil {
+			return num, nil
+		}
+		err = fmt.Errorf("expr %q: %s", x.src, err.Error())
+	}
+	return 0, e(ErrExpr, err.Error(), nil, nil)
+}
@@ -3,6 +3,7 @@ package bobb
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/valyala/fastjson"
 	bolt "go.etcd.io/bbolt"
@@ -18,11 +19,16 @@ type Indexr struct {
 	FldSeparator     string       // separator used in merged field values
 	KeySuffixFormat  string       // using IndexBkt nextSeq#, formatted with leading zeros
 	SkipOnErr        bool         // if true, on error skip writing index entry and don't fail PutRequest
+	TextFlds         []string     // if not empty, text index, see runText
 }
 
 // Run performs indexing for a data key and record by adding/updating index entry in IndexBkt and IndexInvertedBkt based on Indexr settings.
 func (indexr *Indexr) Run(tx *bolt.Tx, dataKey []byte, parsedRec *fastjson.Value, indexingOption string) error {
 
+	if len(indexr.TextFlds) > 0 {
+		return indexr.runText(dataKey, parsedRec, indexingOption)
+	}
+
 	// note - for IndexingOption of IndexingNoUpdate, we do not check for existing index entry for this data key
 	if indexingOption == IndexingNormal { // delete old index entry if exists for this data key
 		oldIndexKey := indexr.IndexInvertedBkt.Get(dataKey)
@@ -65,6 +71,77 @@ func (indexr *Indexr) Run(tx *bolt.Tx, dataKey []byte, parsedRec *fastjson.Value
 	return nil
 }
 
+// runText adds/updates text index entries for a data key.
+// Each word from TextFlds (see textTokens in requests_text.go) has an IndexBkt entry, key is word|dataKey, val is word positions.
+// IndexInvertedBkt entry, key is dataKey, val is the words separated by spaces, so old entries can be found.
+// IndexInvertedBkt Sequence is the # of data keys in the index, used by TextSearchRequest.
+func (indexr *Indexr) runText(dataKey []byte, parsedRec *fastjson.Value, indexingOption string) error {
+	if indexingOption == IndexingNormal { // delete old index entries if exist for this data key
+		if err := indexr.Delete(dataKey); err != nil {
+			return err
+		}
+	}
+	positions, err := textPositions(parsedRec, indexr.TextFlds)
+	if err != nil {
+		if indexr.SkipOnErr {
+			return nil
+		}
+		return fmt.Errorf("error getting text values for %s index, data key %s: %v", indexr.IndexBktName, string(dataKey), err)
+	}
+	isNew := indexr.IndexInvertedBkt.Get(dataKey) == nil // IndexingNoUpdate does not delete old entries
+	words := make([]string, 0, len(positions))
+	for word, wordPositions := range positions {
+		indexKey := word + textKeySeparator + string(dataKey)
+		err = indexr.IndexBkt.Put([]byte(indexKey), []byte(formatPositions(wordPositions)))
+		if err != nil {
+			return fmt.Errorf("IndexBkt Put failed, index key %s, data key %s, %s", indexKey, string(dataKey), err.Error())
+		}
+		words = append(words, word)
+	}
+	err = indexr.IndexInvertedBkt.Put(dataKey, []byte(strings.Join(words, " ")))
+	if err != nil {
+		return fmt.Errorf("IndexInvertedBkt Put failed, data key %s, %s", string(dataKey), err.Error())
+	}
+	if isNew {
+		return indexr.addTextDocCount(1)
+	}
+	return nil
+}
+
+// addTextDocCount adds n to the # of data keys in a text index, kept in IndexInvertedBkt Sequence.
+func (indexr *Indexr) addTextDocCount(n int) error {
+	count := int(indexr.IndexInvertedBkt.Sequence()) + n
+	if err := indexr.IndexInvertedBkt.SetSequence(uint64(max(count, 0))); err != nil {
+		return fmt.Errorf("IndexInvertedBkt SetSequence failed, index bkt %s, %s", indexr.IndexBktName, err.Error())
+	}
+	return nil
+}
+
+// Delete removes the index entries for a data key from IndexBkt and IndexInvertedBkt.
+// Used by DeleteRequest and by Run when an existing entry is replaced.
+func (indexr *Indexr) Delete(dataKey []byte) error {
+	oldIndexVal := indexr.IndexInvertedBkt.Get(dataKey)
+	if oldIndexVal == nil {
+		return nil
+	}
+	if len(indexr.TextFlds) > 0 { // oldIndexVal is list of words
+		for _, word := range strings.Fields(string(oldIndexVal)) {
+			if err := indexr.IndexBkt.Delete([]byte(word + textKeySeparator + string(dataKey))); err != nil {
+				return fmt.Errorf("IndexBkt Delete failed, index bkt %s, data key %s, %s", indexr.IndexBktName, string(dataKey), err.Error())
+			}
+		}
+		if err := indexr.addTextDocCount(-1); err != nil {
+			return err
+		}
+	} else if err := indexr.IndexBkt.Delete(oldIndexVal); err != nil {
+		return fmt.Errorf("IndexBkt Delete failed, index bkt %s, data key %s, %s", indexr.IndexBktName, string(dataKey), err.Error())
+	}
+	if err := indexr.IndexInvertedBkt.Delete(dataKey); err != nil {
+		return fmt.Errorf("IndexInvertedBkt Delete failed, index bkt %s, data key %s, %s", indexr.IndexBktName, string(dataKey), err.Error())
+	}
+	return nil
+}
+
 func NewIndxr(tx *bolt.Tx, setting *IndexSetting) (*Indexr, error) {
 	indexBkt, err := tx.CreateBucketIfNotExists([]byte(setting.IndexBkt))
 	if err != nil {
@@ -75,6 +152,21 @@ func NewIndxr(tx *bolt.Tx, setting *IndexSetting) (*Indexr, error) {
 	if err != nil {
 		return nil, fmt.Errorf("open/create inverted index bkt %s failed: %v", indexInvertedBktName, err)
 	}
+	return newIndexr(setting, indexBkt, indexInvertedBkt), nil
+}
+
+// openIndexr returns an Indexr for existing index bkts, nil if the index or inverted bkt does not exist.
+// Used by DeleteRequest, which must not create index bkts.
+func openIndexr(tx *bolt.Tx, setting *IndexSetting) *Indexr {
+	indexBkt := tx.Bucket([]byte(setting.IndexBkt))
+	indexInvertedBkt := tx.Bucket([]byte(setting.IndexBkt + "_inverted"))
+	if indexBkt == nil || indexInvertedBkt == nil {
+		return nil
+	}
+	return newIndexr(setting, indexBkt, indexInvertedBkt)
+}
+
+func newIndexr(setting *IndexSetting, indexBkt, indexInvertedBkt *bolt.Bucket) *Indexr {
 	var suffixFormat string
 	if setting.KeySuffixWidth > 0 {
 		suffixFormat = "%0" + strconv.Itoa(setting.KeySuffixWidth) + "d"
@@ -87,5 +179,6 @@ func NewIndxr(tx *bolt.Tx, setting *IndexSetting) (*Indexr, error) {
 		FldSeparator:     setting.FldSeparator, // separator used in merged field values
 		KeySuffixFormat:  suffixFormat,         // using IndexBkt nextSeq#, formatted with leading zeros
 		SkipOnErr:        setting.SkipOnErr,    // if true, on error skip writing index entry and don't fail PutRequest
-	}, nil
+		TextFlds:         setting.TextFlds,     // if not empty, text index
+	}
 }
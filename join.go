@@ -0,0 +1,189 @@
+package bobb
+
+/*
+Join loads a value, or a whole record, from a related bkt into each primary record.
+
+The join rec is found using the value of JoinFld in the primary rec:
+  - without IndexBkt, the value is the key of the join rec in JoinBkt
+  - with IndexBkt, the value is encoded the same way as the 1st KeyFld of the index IndexSetting
+    (ex. padding, StrOption, Desc, see indexKeyPrefix in childjoin.go), the 1st index entry beginning with it gives the join rec key,
+    ex. join location by zip to a zip info bkt, using a zip_info index on its zip fld
+
+Joins run in order and JoinFld can be a fld loaded by an earlier join, so joins can be chained,
+ex. request > location (ToFld "location", whole rec) > manager (JoinFld "location.managerId").
+A JoinFld or FromFld containing "." is first looked up as 1 top level fld, then as a nested path.
+If FromFld is empty, the whole join rec is loaded into ToFld as a nested object.
+JoinBkt and IndexBkt must exist, a missing bkt fails the request even if UseDefault is true.
+*/
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/valyala/fastjson"
+	bolt "go.etcd.io/bbolt"
+)
+
+type Join struct {
+	JoinBkt    string // name of related bkt where value is pulled from, default is DataBkt of IndexBkt setting
+	JoinFld    string // fld in primary rec containing key value of join rec, nested flds use ".", can be ToFld of earlier join
+	FromFld    string // fld in join rec where value comes from, if empty whole join rec is loaded
+	ToFld      string // fld in primary rec where value is loaded
+	UseDefault bool   // if join problem, use default value, no error
+	IndexBkt   string // optional, JoinFld value is found in this index of JoinBkt, see comments at top of file
+}
+
+// compiledJoin is a validated Join.
+type compiledJoin struct {
+	Join
+	joinPath []string
+	fromPath []string      // nil if whole rec
+	setting  *IndexSetting // nil if no IndexBkt
+	joinBkt  *bolt.Bucket
+	indexBkt *bolt.Bucket // nil if no IndexBkt
+}
+
+// errJoinBkt is wrapped by compileJoins errors for a missing JoinBkt or IndexBkt, reported as ErrJoinBkt.
+var errJoinBkt = errors.New("invalid join bkt")
+
+// getFld returns the value of fld in rec, fld is 1 top level fld or, if not found, path (fld split on ".").
+func getFld(rec *fastjson.Value, fld string, path []string) *fastjson.Value {
+	if val := rec.Get(fld); val != nil || len(path) == 1 {
+		return val
+	}
+	return rec.Get(path...)
+}
+
+// compileJoins validates joins, opens their bkts and loads the IndexSetting of joins using an index.
+func compileJoins(tx *bolt.Tx, joins []Join) ([]compiledJoin, error) {
+	compiled := make([]compiledJoin, len(joins))
+	for i, join := range joins {
+		if join.JoinFld == "" || join.ToFld == "" {
+			return nil, fmt.Errorf("Join missing JoinFld or ToFld")
+		}
+		c := compiledJoin{Join: join, joinPath: strings.Split(join.JoinFld, ".")}
+		if join.FromFld != "" {
+			c.fromPath = strings.Split(join.FromFld, ".")
+		}
+		if join.IndexBkt != "" {
+			setting, err := joinIndexSetting(tx, join.IndexBkt, join.JoinBkt)
+			if err != nil {
+				return nil, err
+			}
+			c.setting = setting
+			c.JoinBkt = setting.DataBkt
+		}
+		if c.JoinBkt == "" {
+			return nil, fmt.Errorf("Join missing JoinBkt, ToFld %s", join.ToFld)
+		}
+		if c.joinBkt = tx.Bucket([]byte(c.JoinBkt)); c.joinBkt == nil {
+			return nil, fmt.Errorf("%w, %s", errJoinBkt, c.JoinBkt)
+		}
+		if join.IndexBkt != "" {
+			if c.indexBkt = tx.Bucket([]byte(join.IndexBkt)); c.indexBkt == nil {
+				return nil, fmt.Errorf("%w, index %s", errJoinBkt, join.IndexBkt)
+			}
+		}
+		compiled[i] = c
+	}
+	return compiled, nil
+}
+
+// joinLoader loads join values into primary recs, 1 per qryScan.run (not shared by workers).
+// Each join has its own parser, so values set by earlier joins stay valid while later joins parse.
+type joinLoader struct {
+	joins   []compiledJoin
+	parsers []*fastjson.Parser
+	keys    [][]byte          // key of join rec of each join, for current primary rec
+	recs    []*fastjson.Value // parsed join rec of each join, nil if join skipped
+}
+
+func newJoinLoader(joins []compiledJoin) *joinLoader {
+	l := &joinLoader{joins: joins, parsers: make([]*fastjson.Parser, len(joins)),
+		keys: make([][]byte, len(joins)), recs: make([]*fastjson.Value, len(joins))}
+	for i := range joins {
+		l.parsers[i] = parserPool.Get()
+	}
+	return l
+}
+
+func (l *joinLoader) release() {
+	for _, parser := range l.parsers {
+		parserPool.Put(parser)
+	}
+}
+
+// load adds values from related bkts to parsed primary data record. See Join type for details.
+// If UseDefault true, on error, no join value(s) added to rec and no error returned.
+// Client side UnMarshal will load default (zero value) into struct join flds.
+// Parm lookups is incremented for each join bkt or index get.
+func (l *joinLoader) load(parsedRec *fastjson.Value, lookups *int) (recBytes []byte, bErr *BobbErr) {
+	for i := range l.joins {
+		join := &l.joins[i]
+		l.keys[i], l.recs[i] = nil, nil
+		parsedJoinRec, bErr := l.joinRec(parsedRec, i, lookups)
+		if bErr != nil {
+			if join.UseDefault {
+				continue
+			}
+			return nil, bErr
+		}
+		joinVal := parsedJoinRec
+		if join.fromPath != nil {
+			joinVal = getFld(parsedJoinRec, join.FromFld, join.fromPath)
+		}
+		if joinVal == nil {
+			if join.UseDefault {
+				continue
+			}
+			emsg := fmt.Sprintf("join from fld not found, key %s, FromFld %s", l.keys[i], join.FromFld)
+			return nil, e(ErrJoinFromFld, emsg, nil, nil)
+		}
+		parsedRec.Set(join.ToFld, joinVal)
+	}
+	return parsedRec.MarshalTo(nil), nil
+}
+
+// joinRec returns the parsed join rec of join i. If an earlier join read the same rec, it is reused.
+func (l *joinLoader) joinRec(parsedRec *fastjson.Value, i int, lookups *int) (*fastjson.Value, *BobbErr) {
+	join := &l.joins[i]
+	keyVal := getFld(parsedRec, join.JoinFld, join.joinPath) // get key of record in join bkt
+	if keyVal == nil || keyVal.Type() == fastjson.TypeNull || (join.setting == nil && keyVal.Type() != fastjson.TypeString) {
+		return nil, e(ErrJoinFld, fmt.Sprintf("invalid join fld, %s", join.JoinFld), nil, nil)
+	}
+	joinKey := keyVal.GetStringBytes()
+	if join.setting != nil {
+		prefix, err := indexKeyPrefix(join.setting, keyVal)
+		if err != nil {
+			return nil, e(ErrJoinFld, fmt.Sprintf("invalid join fld %s value - %s", join.JoinFld, err.Error()), nil, nil)
+		}
+		*lookups++
+		k, v := join.indexBkt.Cursor().Seek([]byte(prefix))
+		if k == nil || !bytes.HasPrefix(k, []byte(prefix)) {
+			emsg := fmt.Sprintf("join value %s not in join index %s", keyVal, join.IndexBkt)
+			return nil, e(ErrJoinKey, emsg, nil, nil)
+		}
+		joinKey = v // index value is data key
+	}
+	for j := range i { // same rec read by earlier join
+		if l.recs[j] != nil && l.joins[j].JoinBkt == join.JoinBkt && bytes.Equal(l.keys[j], joinKey) {
+			l.keys[i], l.recs[i] = l.keys[j], l.recs[j]
+			return l.recs[i], nil
+		}
+	}
+	joinRec := join.joinBkt.Get(joinKey) // get record from join bkt
+	*lookups++
+	if joinRec == nil {
+		emsg := fmt.Sprintf("join key %s not in join bkt %s", string(joinKey), join.JoinBkt)
+		return nil, e(ErrJoinKey, emsg, nil, nil)
+	}
+	parsedJoinRec, err := l.parsers[i].ParseBytes(joinRec) // parse join record
+	if err != nil {
+		emsg := fmt.Sprintf("error parsing join rec, key %s, val %s", joinKey, string(joinRec))
+		return nil, e(ErrJoinParse, emsg, nil, nil)
+	}
+	l.keys[i], l.recs[i] = joinKey, parsedJoinRec
+	return parsedJoinRec, nil
+}
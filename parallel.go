@@ -0,0 +1,176 @@
+package bobb
+
+/*
+Parallel execution of QryRequest (QryRequest.Parallel > 1).
+
+The key range is split into partitions without reading the range. Ranges are split in half,
+at the 1st key >= the midpoint of the range start and end keys (keys as big endian numbers, as
+SampleSeek in requests_sample.go), so only a few keys are read per partition. Partition sizes follow
+the key distribution, partitionsPerWorker partitions per worker even out uneven partitions.
+Workers take partitions from a channel and scan them with qryScan.run, each with its own parsers from parserPool.
+
+Partition results are merged in key order (no SortKeys) or sort order, with ties in key order,
+so results do not depend on the # of workers or partitions.
+
+Parallel is not used (the request runs sequentially) when:
+  - IndexBkt, Reverse or Limit is set, these depend on reading keys in order
+  - the request is a FacetRequest
+  - the range has fewer than 2*minParallelKeys keys, only these keys are read to check
+*/
+
+import (
+	"bytes"
+	"math/big"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const minParallelKeys = 64 // ranges with fewer than 2*minParallelKeys keys run sequentially
+
+const partitionsPerWorker = 4 // more partitions than workers, so uneven partitions don't idle workers
+
+const maxQryWorkers = 16 // limits QryRequest.Parallel, workers above GOMAXPROCS share cpus
+
+// qryPartitions returns the key ranges used for a parallel run, nil if run is sequential.
+// Partition StartKey and EndKey are actual keys, so each partition is a plain range (never a prefix match).
+func qryPartitions(req *QryRequest, bkt *bolt.Bucket) []KeyRange {
+	if req.Parallel < 2 || req.IndexBkt != "" || req.Reverse || req.Limit > 0 || req.facets != nil {
+		return nil
+	}
+	workers := min(req.Parallel, maxQryWorkers)
+	readLoop := NewReadLoop(bkt, nil)
+	first, _, _ := readLoop.Start(req.StartKey, req.EndKey, 0)
+	k := first
+	for n := 0; k != nil && n < 2*minParallelKeys; n++ {
+		k, _, _ = readLoop.Next()
+	}
+	if k == nil {
+		return nil // small range
+	}
+	first = bytes.Clone(first)
+	readLoop.Reverse = true
+	last, _, _ := readLoop.Start(req.StartKey, req.EndKey, 0)
+	last = bytes.Clone(last)
+
+	// ranges are split in half until there are partitionCnt, each split is 2 seeks
+	partitionCnt := workers * partitionsPerWorker
+	partitions := []KeyRange{{StartKey: string(first), EndKey: string(last)}}
+	csr := bkt.Cursor()
+	for len(partitions) < partitionCnt {
+		split := make([]KeyRange, 0, 2*len(partitions))
+		for i, partition := range partitions {
+			if len(split)+len(partitions)-i < partitionCnt {
+				if left, right, ok := splitKeyRange(csr, partition); ok {
+					split = append(split, left, right)
+					continue
+				}
+			}
+			split = append(split, partition)
+		}
+		if len(split) == len(partitions) {
+			break // no range has 2 or more keys
+		}
+		partitions = split
+	}
+	if len(partitions) < 2 {
+		return nil
+	}
+	return partitions
+}
+
+// splitKeyRange splits r at the 1st key >= the midpoint of its start and end keys, as big endian numbers.
+// Start and end are actual keys, so split points follow the key distribution (ex. only digits used).
+// Returns false if r has only 1 key.
+func splitKeyRange(csr *bolt.Cursor, r KeyRange) (left, right KeyRange, ok bool) {
+	width := max(len(r.StartKey), len(r.EndKey)) + 1 // extra byte, midpoint of adjacent keys is between them
+	mid := new(big.Int).SetBytes(padRight([]byte(r.StartKey), width))
+	mid.Add(mid, new(big.Int).SetBytes(padRight([]byte(r.EndKey), width))).Rsh(mid, 1)
+	k, _ := csr.Seek(bytes.TrimRight(mid.FillBytes(make([]byte, width)), "\x00"))
+	if k != nil && string(k) == r.StartKey {
+		k, _ = csr.Next()
+	}
+	if k == nil || string(k) > r.EndKey {
+		return left, right, false
+	}
+	right = KeyRange{StartKey: string(k), EndKey: r.EndKey}
+	prevKey, _ := csr.Prev()
+	left = KeyRange{StartKey: r.StartKey, EndKey: string(prevKey)}
+	return left, right, true
+}
+
+// runParallel scans partitions concurrently, results are returned in partition (key) order.
+// If a partition fails (ErrLimit exceeded), partitions after it are skipped.
+// Param seqBase is added to the read order seqs of all partitions (used by UnionQryRequest).
+func (scan *qryScan) runParallel(bkt *bolt.Bucket, partitions []KeyRange, seqBase int) []*qryScanResult {
+	results := make([]*qryScanResult, len(partitions))
+	workers := min(scan.req.Parallel, maxQryWorkers, len(partitions))
+
+	todo := make(chan int, len(partitions))
+	for i := range partitions {
+		todo <- i
+	}
+	close(todo)
+
+	var mu sync.Mutex
+	failedAt := len(partitions) // 1st failed partition
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range todo {
+				mu.Lock()
+				skip := i > failedAt
+				mu.Unlock()
+				if skip {
+					results[i] = new(qryScanResult)
+					continue
+				}
+				// read order of each partition starts after all possible seqs of previous partitions
+				result := scan.run(NewReadLoop(bkt, nil), partitions[i].StartKey, partitions[i].EndKey, seqBase+i<<40)
+				if result.failed {
+					mu.Lock()
+					failedAt = min(failedAt, i)
+					mu.Unlock()
+				}
+				results[i] = result
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// mergeSortRecs merges the sorted recs of each result and returns the values (and meta if WithMeta) of the first top recs (all if top is 0).
+// Each step takes the smallest head of the result lists, the # of lists is small (# partitions).
+func mergeSortRecs(results []*qryScanResult, top int) ([][]byte, []RecMeta) {
+	total := 0
+	for _, result := range results {
+		total += len(result.sortRecs)
+	}
+	if top > 0 && top < total {
+		total = top
+	}
+	recs := make([][]byte, 0, total)
+	var metas []RecMeta                // loaded if recs have meta (WithMeta)
+	heads := make([]int, len(results)) // index of next rec in each result
+	for len(recs) < total {
+		best := -1
+		for i, result := range results {
+			if heads[i] >= len(result.sortRecs) {
+				continue
+			}
+			if best == -1 || compareSortRecs(result.sortRecs[heads[i]], results[best].sortRecs[heads[best]]) < 0 {
+				best = i
+			}
+		}
+		rec := results[best].sortRecs[heads[best]]
+		recs = append(recs, rec.Value)
+		if rec.meta != nil {
+			metas = append(metas, *rec.meta)
+		}
+		heads[best]++
+	}
+	return recs, metas
+}
@@ -0,0 +1,91 @@
+package bobb
+
+/*
+Projection returns only selected flds of each record, used by the Fields option of
+GetRequest, GetAllRequest, GetByIndexRequest and QryRequest.
+
+Each Fields entry is a fld name, a nested fld path or a rename:
+  - "city"                  - top level fld
+  - "agent.name"            - nested fld, returned as {"agent":{"name":...}}
+  - "agentName=agent.name"  - fld returned with a different name, {"agentName":...}
+
+Values keep their original json type (string, number, object, array, ...).
+Flds not found in a record are omitted, so client side unmarshal loads the zero value.
+*/
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/valyala/fastjson"
+)
+
+// projFld is a parsed Fields entry.
+type projFld struct {
+	from []string // path of fld in record
+	to   []string // path of fld in projected record
+}
+
+// projection is a parsed Fields list, created by newProjection.
+// The arena is reused for each record, so a projection must not be shared by concurrent requests.
+type projection struct {
+	flds  []projFld
+	arena fastjson.Arena
+}
+
+// newProjection parses the Fields entries. Returns nil if fields is empty (no projection).
+func newProjection(fields []string) (*projection, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	p := &projection{flds: make([]projFld, 0, len(fields))}
+	toNames := make(map[string]bool, len(fields))
+	for _, fld := range fields {
+		toName, fromName, renamed := strings.Cut(fld, "=")
+		if !renamed {
+			fromName = toName
+		}
+		toName, fromName = strings.TrimSpace(toName), strings.TrimSpace(fromName)
+		if toName == "" || fromName == "" || strings.Contains(fromName+"."+toName, "..") {
+			return nil, fmt.Errorf("invalid Fields entry %q", fld)
+		}
+		if toNames[toName] {
+			return nil, fmt.Errorf("duplicate Fields entry %q", toName)
+		}
+		toNames[toName] = true
+		p.flds = append(p.flds, projFld{from: strings.Split(fromName, "."), to: strings.Split(toName, ".")})
+	}
+	return p, nil
+}
+
+// apply returns a json object containing only the projected flds of parsedRec.
+func (p *projection) apply(parsedRec *fastjson.Value) []byte {
+	p.arena.Reset()
+	result := p.arena.NewObject()
+	for _, fld := range p.flds {
+		val := parsedRec.Get(fld.from...)
+		if val == nil {
+			continue
+		}
+		obj := result
+		for _, name := range fld.to[:len(fld.to)-1] { // create nested objects as needed
+			child := obj.Get(name)
+			if child == nil || child.Type() != fastjson.TypeObject {
+				child = p.arena.NewObject()
+				obj.Set(name, child)
+			}
+			obj = child
+		}
+		obj.Set(fld.to[len(fld.to)-1], val)
+	}
+	return result.MarshalTo(nil)
+}
+
+// applyBytes parses rec and returns the projected rec.
+func (p *projection) applyBytes(parser *fastjson.Parser, rec []byte) ([]byte, error) {
+	parsedRec, err := parser.ParseBytes(rec)
+	if err != nil {
+		return nil, err
+	}
+	return p.apply(parsedRec), nil
+}
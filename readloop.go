@@ -1,6 +1,7 @@
 package bobb
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
 
@@ -8,7 +9,8 @@ import (
 )
 
 // ReadLoop type provides functionality for reading bucket records sequentially.
-// Optional Index, StartKey, EndKey
+// Optional Index, StartKey, EndKey, Reverse
+// When paging with Reverse, use resp.NextKey as the EndKey of the next request.
 type ReadLoop struct {
 	Bkt         *bolt.Bucket // data bkt
 	Index       *bolt.Bucket // index bkt
@@ -18,28 +20,23 @@ type ReadLoop struct {
 	MatchPrefix bool         // if StartKey == EndKey, rec key prefix must match StartKey
 	UsingIndex  bool         // indicates if index is being used
 	NextKey     []byte       // used for resp.NextKey when range-end or limit hit
+	DataKey     []byte       // key of current data rec, index value if UsingIndex, else same as k
 	Limit       int          // results limit
 	Count       int          // Count equal Limit triggers loop end, Count updated by caller
+	Reverse     bool         // if true, loop reads keys in descending order, from EndKey back to StartKey
 }
 
 // Start method sets the cursor and returns 1st key/value pair.
 // If UsingIndex, Csr will be set using index bkt.
 // If startKey == "", loop starts with 1st key.
 // If endKey == "", loop ends with last key.
+// If Reverse, loop starts with last key in range and ends with 1st key in range.
 func (loop *ReadLoop) Start(startKey, endKey string, limit int) (k, v []byte, bErr *BobbErr) {
 	if loop.UsingIndex {
 		loop.Csr = loop.Index.Cursor()
 	} else {
 		loop.Csr = loop.Bkt.Cursor()
 	}
-	if startKey == "" {
-		k, v = loop.Csr.First()
-	} else {
-		k, v = loop.Csr.Seek([]byte(startKey))
-	}
-	if k == nil {
-		return
-	}
 	loop.StartKey = startKey
 	loop.EndKey = endKey
 	loop.Limit = limit
@@ -48,16 +45,24 @@ func (loop *ReadLoop) Start(startKey, endKey string, limit int) (k, v []byte, bE
 	if loop.StartKey != "" && loop.StartKey == loop.EndKey {
 		loop.MatchPrefix = true
 	}
-	if loop.MatchPrefix {
-		if !strings.HasPrefix(string(k), loop.StartKey) {
-			k, v = nil, nil
-			return
-		}
-	} else if loop.EndKey != "" && string(k) > loop.EndKey {
+	switch {
+	case loop.Reverse:
+		k, v = loop.seekLast()
+	case startKey == "":
+		k, v = loop.Csr.First()
+	default:
+		k, v = loop.Csr.Seek([]byte(startKey))
+	}
+	if k == nil {
+		return
+	}
+	if !loop.inRange(k) {
 		k, v = nil, nil
 		return
 	}
+	loop.DataKey = k
 	if loop.UsingIndex {
+		loop.DataKey = v
 		dataVal := loop.Bkt.Get(v) // v is value of index which is key of data record
 		if dataVal == nil {
 			emsg := fmt.Sprintf("index val %s not key in data bkt", string(v))
@@ -73,8 +78,13 @@ func (loop *ReadLoop) Start(startKey, endKey string, limit int) (k, v []byte, bE
 // Next returns next key/value pair or nil/nil if loop ended.
 // If UsingIndex, key is index key. Value is always from data bkt.
 // If k is outside of range, loop.NextKey loaded with k.
+// If Reverse, the next key is the previous key in bkt order.
 func (loop *ReadLoop) Next() (k, v []byte, bErr *BobbErr) {
-	k, v = loop.Csr.Next()
+	if loop.Reverse {
+		k, v = loop.Csr.Prev()
+	} else {
+		k, v = loop.Csr.Next()
+	}
 	if k == nil {
 		return
 	}
@@ -83,18 +93,14 @@ func (loop *ReadLoop) Next() (k, v []byte, bErr *BobbErr) {
 		k, v = nil, nil
 		return
 	}
-	if loop.MatchPrefix {
-		if !strings.HasPrefix(string(k), loop.StartKey) {
-			loop.NextKey = k
-			k, v = nil, nil
-			return
-		}
-	} else if loop.EndKey != "" && string(k) > loop.EndKey {
+	if !loop.inRange(k) {
 		loop.NextKey = k
 		k, v = nil, nil
 		return
 	}
+	loop.DataKey = k
 	if loop.UsingIndex {
+		loop.DataKey = v
 		dataVal := loop.Bkt.Get(v) // v is value of index which is key of data record
 		if dataVal == nil {
 			emsg := fmt.Sprintf("index val %s not key in data bkt", string(v))
@@ -106,6 +112,62 @@ func (loop *ReadLoop) Next() (k, v []byte, bErr *BobbErr) {
 	return
 }
 
+// inRange returns true if k is inside the loop range.
+// Only the end of the range in the direction of the loop is checked, the cursor starts inside the range.
+func (loop *ReadLoop) inRange(k []byte) bool {
+	if loop.MatchPrefix {
+		return strings.HasPrefix(string(k), loop.StartKey)
+	}
+	if loop.Reverse {
+		return loop.StartKey == "" || string(k) >= loop.StartKey
+	}
+	return loop.EndKey == "" || string(k) <= loop.EndKey
+}
+
+// seekLast positions Csr on the last key <= EndKey, or the last key beginning with prefix if MatchPrefix.
+// Used by Start when loop.Reverse is true.
+func (loop *ReadLoop) seekLast() (k, v []byte) {
+	var seekKey []byte
+	if loop.MatchPrefix {
+		seekKey = prefixEnd([]byte(loop.StartKey))
+	} else if loop.EndKey != "" {
+		seekKey = []byte(loop.EndKey)
+	}
+	if seekKey == nil {
+		return loop.Csr.Last()
+	}
+	k, v = loop.Csr.Seek(seekKey) // 1st key >= seekKey
+	if k == nil {
+		return loop.Csr.Last()
+	}
+	if loop.MatchPrefix || string(k) > loop.EndKey {
+		return loop.Csr.Prev()
+	}
+	return
+}
+
+// prefixEnd returns the 1st key greater than all keys beginning with prefix, nil if there isn't one.
+// Ex. "abc" > "abd"
+func prefixEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// recMeta returns the RecMeta of the current rec, k is the key returned by Start or Next, size is the data value size.
+func (loop *ReadLoop) recMeta(k []byte, size int) RecMeta {
+	meta := RecMeta{Key: string(loop.DataKey), Size: size}
+	if loop.UsingIndex {
+		meta.IndexKey = string(k)
+	}
+	return meta
+}
+
 // Create and return new instance of ReadLoop.
 // Parm bkt is pointer to data bucket.
 // Parm index is pointer to index bucket. If nil, no index.
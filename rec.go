@@ -3,11 +3,13 @@
 package bobb
 
 import (
+	"cmp"
 	"fmt"
 	"log"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/valyala/fastjson"
@@ -36,10 +38,8 @@ func PlainString(in string) string {
 // DefaultNever would return error if fld value is null or fld not found.
 func MergeFlds(parsedRec *fastjson.Value, flds []FldFormat, separator string) (mergedVal string, err error) {
 	var bErr *BobbErr
-	var format string
 	var intVal int
 	var strVal string
-	var strOption string
 	var useDefault string
 	formattedFlds := make([]string, len(flds))
 	for i, fld := range flds {
@@ -50,41 +50,144 @@ func MergeFlds(parsedRec *fastjson.Value, flds []FldFormat, separator string) (m
 		if !slices.Contains(AllDefaultCodes, useDefault) {
 			return "", fmt.Errorf("MergeFlds, invalid UseDefault code for fld %s, must be one of bobb.DefaultAlways, bobb.DefaultNever, bobb.DefaultIsNull, bobb.DefaultNotFound", fld.FldName)
 		}
-		fmtLength := strconv.Itoa(fld.Length)
 		switch fld.FldType {
 		case FldTypeInt:
 			intVal, bErr = parsedRecGetInt(parsedRec, fld.FldName, useDefault)
 			if bErr != nil {
 				return "", fmt.Errorf("MergeFlds, error getting int value for fld %s, %s", fld.FldName, bErr.Msg)
 			}
-			format = "%0" + fmtLength + "d"
-			formattedFlds[i] = fmt.Sprintf(format, intVal)
 		case FldTypeStr:
-			strOption = fld.StrOption
-			if strOption == "" {
-				strOption = StrLowerCase
-			}
-			if !slices.Contains(AllStrOptions, strOption) {
-				return "", fmt.Errorf("MergeFlds, invalid StrOption for fld %s, must be one of bobb.StrPlain, bobb.StrLowerCase, bobb.StrAsIs", fld.FldName)
-			}
-			strVal, bErr = parsedRecGetStr(parsedRec, fld.FldName, useDefault, strOption)
+			strVal, bErr = parsedRecGetStr(parsedRec, fld.FldName, useDefault)
 			if bErr != nil {
 				return "", fmt.Errorf("MergeFlds, error getting str value for fld %s, %s", fld.FldName, bErr.Msg)
 			}
-			if len(strVal) > fld.Length {
-				strVal = strVal[:fld.Length]
-			}
-			format = "%-" + fmtLength + "s"
-			formattedFlds[i] = fmt.Sprintf(format, strVal)
 		default:
 			log.Println("MergeFlds, invalid fld type, must be string or int", fld.FldName, fld.FldType)
 			return
 		}
+		formattedFlds[i], err = FormatFldVal(fld, strVal, intVal)
+		if err != nil {
+			return "", err
+		}
 	}
 	mergedVal = strings.Join(formattedFlds, separator)
 	return
 }
 
+// FormatFldVal formats a single value as it appears in a merged key, see MergeFlds.
+// For FldTypeStr, strVal is transformed and converted (see convertStr), then padded to fld.Length.
+// For FldTypeInt, intVal is formatted with leading zeros to fld.Length (see formatInt).
+// If fld.Desc, the result is inverted so it sorts in descending order.
+// Also used to encode logical values into index keys, see GetByIndexRequest.
+func FormatFldVal(fld FldFormat, strVal string, intVal int) (formatted string, err error) {
+	fmtLength := strconv.Itoa(fld.Length)
+	switch fld.FldType {
+	case FldTypeInt:
+		formatted, err = formatInt(intVal, fld.Length)
+		if err != nil {
+			return "", fmt.Errorf("MergeFlds, fld %s, %s", fld.FldName, err.Error())
+		}
+	case FldTypeStr:
+		strVal, err = convertStr(fld, strVal)
+		if err != nil {
+			return "", err
+		}
+		formatted = fmt.Sprintf("%-"+fmtLength+"s", strVal)
+	default:
+		return "", fmt.Errorf("invalid fld type for fld %s, must be string or int", fld.FldName)
+	}
+	if fld.Desc {
+		formatted = descStr(formatted)
+	}
+	return formatted, nil
+}
+
+// convertStr applies fld.Transform and fld.StrOption (default StrLowerCase) to strVal and truncates it to fld.Length.
+// Date transforms are applied before StrOption, TransformPrefix after.
+func convertStr(fld FldFormat, strVal string) (string, error) {
+	var err error
+	switch fld.Transform {
+	case "", TransformPrefix:
+	case TransformDay:
+		strVal, err = truncateDate(strVal, time.DateOnly)
+	case TransformMonth:
+		strVal, err = truncateDate(strVal, "2006-01")
+	default:
+		err = fmt.Errorf("invalid Transform %s", fld.Transform)
+	}
+	if err != nil {
+		return "", fmt.Errorf("MergeFlds, fld %s, %s", fld.FldName, err.Error())
+	}
+	strOption := fld.StrOption
+	if strOption == "" {
+		strOption = StrLowerCase
+	}
+	switch strOption {
+	case StrLowerCase:
+		strVal = strings.ToLower(strVal)
+	case StrPlain:
+		strVal = PlainString(strVal)
+	case StrAsIs:
+	default:
+		return "", fmt.Errorf("MergeFlds, invalid StrOption for fld %s, must be one of bobb.StrPlain, bobb.StrLowerCase, bobb.StrAsIs", fld.FldName)
+	}
+	if fld.Transform == TransformPrefix && len(strVal) > fld.PrefixLen {
+		strVal = strVal[:fld.PrefixLen]
+	}
+	if len(strVal) > fld.Length {
+		strVal = strVal[:fld.Length]
+	}
+	return strVal, nil
+}
+
+// dateLayouts are the formats accepted by truncateDate.
+var dateLayouts = []string{time.RFC3339Nano, time.DateTime, time.DateOnly, "2006-01"}
+
+// truncateDate parses a date or timestamp string and returns it formatted with layout.
+// Empty string (fld not found or null with UseDefault) is returned as is.
+func truncateDate(dateVal, layout string) (string, error) {
+	if dateVal == "" {
+		return "", nil
+	}
+	for _, dateLayout := range dateLayouts {
+		if t, err := time.Parse(dateLayout, dateVal); err == nil {
+			return t.Format(layout), nil
+		}
+	}
+	return "", fmt.Errorf("value %s is not a valid date", dateVal)
+}
+
+// formatInt formats intVal with leading zeros to length.
+// Negative values are encoded as "-" followed by 10^(length-1) + intVal, with leading zeros to length-1.
+// Since "-" sorts before digits, negative values sort before positive values and in numeric order.
+// Ex. length 5: -1 > "-9999", -999 > "-9001", 42 > "00042"
+func formatInt(intVal, length int) (string, error) {
+	if intVal >= 0 {
+		return fmt.Sprintf("%0"+strconv.Itoa(length)+"d", intVal), nil
+	}
+	limit := 1
+	for range length - 1 {
+		limit *= 10
+	}
+	if length < 2 || intVal < -limit {
+		return "", fmt.Errorf("negative value %d does not fit in length %d", intVal, length)
+	}
+	return fmt.Sprintf("-%0"+strconv.Itoa(length-1)+"d", limit+intVal), nil
+}
+
+// descStr inverts each printable ascii char so values sort in descending order, ex. "0" > "n", "9" > "e", " " > "~".
+// Inverted values remain printable, so keys are still valid json strings (ex. resp.NextKey).
+// Other chars are left as is.
+func descStr(in string) string {
+	out := []byte(in)
+	for i, c := range out {
+		if c >= ' ' && c <= '~' {
+			out[i] = ' ' + '~' - c
+		}
+	}
+	return string(out)
+}
+
 // parsedRecGetStr returns the string value for specified fld.
 // Parm "option" controls conversion, see Str* codes in codes.go
 // Parm useDefault controls how fld not found or null is handled (whether ""/no error or error is returned).
@@ -159,29 +262,46 @@ var nIntOps = []string{FindEquals, FindLessThan, FindGreaterThan}
 // parseRecFind determines if rec value(s) meet all find conditions using already parsed rec.
 func parsedRecFind(parsedRec *fastjson.Value, conditions []FindCondition) (keep bool, bErr *BobbErr) {
 	var conditionMet bool
-	var n int // compare result  1:greater, -1:less, 0:equal
-	var recValStr string
+	var n int                    // compare result  1:greater, -1:less, 0:equal
+	var recValStr, valStr string // valStr is condition.ValStr or result of condition.ValExpr
 	var recValInt int
+	var recNum, valNum float64 // used when condition has expressions
 	for _, condition := range conditions {
 		conditionMet = false
+		valStr = condition.ValStr
 		switch {
 		case slices.Contains(StrFindOps, condition.Op):
-			switch condition.StrOption {
-			case StrLowerCase:
-				recValStr, bErr = parsedRecGetStr(parsedRec, condition.Fld, condition.UseDefault, StrLowerCase)
-			case StrPlain:
-				recValStr, bErr = parsedRecGetStr(parsedRec, condition.Fld, condition.UseDefault, StrPlain)
-			case StrAsIs:
-				recValStr, bErr = parsedRecGetStr(parsedRec, condition.Fld, condition.UseDefault)
-			default:
-				log.Panicln("invalid findCondition.StrOption", condition.StrOption) // should already be validated
+			if condition.expr != nil {
+				recValStr, bErr = exprGetStr(parsedRec, condition.expr, condition.StrOption)
+			} else {
+				recValStr, bErr = parsedRecGetFindStr(parsedRec, condition)
+			}
+			if bErr == nil && condition.valExpr != nil {
+				valStr, bErr = exprGetStr(parsedRec, condition.valExpr, condition.StrOption)
 			}
 			if bErr != nil {
 				return
 			}
 			if slices.Contains(nStrOps, condition.Op) { // n indicates if recValStr is less than, equal to, or greater than compareValStr
-				n = strings.Compare(recValStr, condition.ValStr)
+				n = strings.Compare(recValStr, valStr)
+			}
+		case slices.Contains(IntFindOps, condition.Op) && (condition.expr != nil || condition.valExpr != nil):
+			// expression results may be float, so compare as float
+			if condition.expr != nil {
+				recNum, bErr = exprGetNum(parsedRec, condition.expr)
+			} else {
+				recValInt, bErr = parsedRecGetInt(parsedRec, condition.Fld, condition.UseDefault)
+				recNum = float64(recValInt)
 			}
+			valNum = float64(condition.ValInt)
+			if bErr == nil && condition.valExpr != nil {
+				valNum, bErr = exprGetNum(parsedRec, condition.valExpr)
+			}
+			if bErr != nil {
+				return
+			}
+			recValInt = int(recNum)
+			n = cmp.Compare(recNum, valNum)
 		case slices.Contains(IntFindOps, condition.Op):
 			recValInt, bErr = parsedRecGetInt(parsedRec, condition.Fld, condition.UseDefault)
 			if bErr != nil {
@@ -195,6 +315,10 @@ func parsedRecFind(parsedRec *fastjson.Value, conditions []FindCondition) (keep
 
 		switch condition.Op {
 		case FindExists, FindIsNull:
+			if condition.SubQry != nil { // exists, subquery returned recs
+				conditionMet = condition.subExists
+				break
+			}
 			val := parsedRec.Get(condition.Fld)
 			if val != nil {
 				if condition.Op == FindExists {
@@ -216,28 +340,40 @@ func parsedRecFind(parsedRec *fastjson.Value, conditions []FindCondition) (keep
 				conditionMet = true
 			}
 		case FindStartsWith:
-			if strings.HasPrefix(recValStr, condition.ValStr) {
+			if strings.HasPrefix(recValStr, valStr) {
 				conditionMet = true
 			}
 		case FindEndsWith:
-			if strings.HasSuffix(recValStr, condition.ValStr) {
+			if strings.HasSuffix(recValStr, valStr) {
 				conditionMet = true
 			}
 		case FindContains:
-			if strings.Contains(recValStr, condition.ValStr) {
+			if strings.Contains(recValStr, valStr) {
 				conditionMet = true
 			}
 		case FindContainsWord:
 			words := strings.Fields(recValStr)
-			if slices.Contains(words, condition.ValStr) {
+			if slices.Contains(words, valStr) {
 				conditionMet = true
 			}
 		case FindInStrList:
-			if slices.Contains(condition.StrList, recValStr) {
+			if condition.strSet != nil {
+				conditionMet = condition.strSet[recValStr]
+			} else if slices.Contains(condition.StrList, recValStr) {
 				conditionMet = true
 			}
 		case FindInIntList:
-			if slices.Contains(condition.IntList, recValInt) {
+			if condition.intSet != nil {
+				conditionMet = condition.intSet[recValInt]
+			} else if slices.Contains(condition.IntList, recValInt) {
+				conditionMet = true
+			}
+		case FindRegex:
+			if condition.regex.MatchString(recValStr) {
+				conditionMet = true
+			}
+		case FindFuzzy:
+			if editDistance(recValStr, valStr, condition.MaxDist) <= condition.MaxDist {
 				conditionMet = true
 			}
 		default:
@@ -260,3 +396,49 @@ func parsedRecFind(parsedRec *fastjson.Value, conditions []FindCondition) (keep
 
 	return // rec meets all conditions
 }
+
+// editDistance returns the Levenshtein distance (# of single char inserts, deletes or substitutions) between a and b.
+// Stops early and returns maxDist+1 once the distance is known to be greater than maxDist.
+func editDistance(a, b string, maxDist int) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra)-len(rb) > maxDist || len(rb)-len(ra) > maxDist {
+		return maxDist + 1
+	}
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > maxDist {
+			return maxDist + 1
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// parsedRecGetFindStr returns the condition.Fld value of parsedRec converted using condition.StrOption.
+func parsedRecGetFindStr(parsedRec *fastjson.Value, condition FindCondition) (recValStr string, bErr *BobbErr) {
+	switch condition.StrOption {
+	case StrLowerCase:
+		recValStr, bErr = parsedRecGetStr(parsedRec, condition.Fld, condition.UseDefault, StrLowerCase)
+	case StrPlain:
+		recValStr, bErr = parsedRecGetStr(parsedRec, condition.Fld, condition.UseDefault, StrPlain)
+	case StrAsIs:
+		recValStr, bErr = parsedRecGetStr(parsedRec, condition.Fld, condition.UseDefault)
+	default:
+		log.Panicln("invalid findCondition.StrOption", condition.StrOption) // should already be validated
+	}
+	return
+}
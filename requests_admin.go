@@ -0,0 +1,247 @@
+package bobb
+
+/*
+BktAdminRequest performs bucket administration operations that keep index metadata consistent.
+Unlike BktRequest "delete", these operations also handle the dependents of a data bkt:
+  - index_settings entries where IndexSetting.DataBkt is the data bkt
+  - index bkts and their _inverted bkts
+  - the bktname_putlog bkt
+
+All changes are made in a single update transaction, so they are applied atomically.
+Dependents that could not be found are skipped and reported in Response.Errs.
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BktAdminRequest Operation codes
+const (
+	AdminDropIndex = "dropindex" // delete IndexBkt, its _inverted bkt and its index_settings entry
+	AdminDropBkt   = "dropbkt"   // delete BktName and all its dependents
+	AdminRenameBkt = "renamebkt" // rename BktName and all its dependents to NewBktName
+	AdminCopyBkt   = "copybkt"   // copy BktName and all its dependents to NewBktName
+)
+
+// BktAdminRequest is used to drop, rename or copy a data bkt along with its dependents, or to drop an index.
+// For rename and copy, index bkt names are changed by replacing the BktName prefix with NewBktName.
+// Ex. renaming "order" to "order_2024" changes index "order_date_index" to "order_2024_date_index".
+// Response.Recs contains names of bkts created or deleted. Response.PutCnt is number of entries copied.
+type BktAdminRequest struct {
+	BktName    string // data bkt, not used by AdminDropIndex
+	NewBktName string // used by AdminRenameBkt and AdminCopyBkt, must not already exist
+	IndexBkt   string // used by AdminDropIndex
+	Operation  string // see Admin* codes above
+}
+
+func (req BktAdminRequest) IsUpdtReq() bool {
+	return true
+}
+
+func (req *BktAdminRequest) Run(tx *bolt.Tx) (*Response, error) {
+
+	resp := new(Response)
+	resp.Recs = make([][]byte, 0, 10)
+	resp.keyRecs = true
+	var err error
+
+	op := strings.ToLower(req.Operation)
+	switch op {
+	case AdminDropIndex:
+		if req.IndexBkt == "" {
+			resp.Status = StatusFail
+			resp.Msg = "IndexBkt not specified in request"
+			return resp, nil
+		}
+		err = dropIndex(tx, resp, req.IndexBkt)
+	case AdminDropBkt:
+		if tx.Bucket([]byte(req.BktName)) == nil {
+			resp.Status = StatusFail
+			resp.Msg = fmt.Sprintf("bucket %s not found", req.BktName)
+			return resp, nil
+		}
+		err = dropBktAndDependents(tx, resp, req.BktName)
+	case AdminRenameBkt, AdminCopyBkt:
+		if req.NewBktName == "" || req.NewBktName == req.BktName {
+			resp.Status = StatusFail
+			resp.Msg = "NewBktName must be specified and differ from BktName"
+			return resp, nil
+		}
+		if tx.Bucket([]byte(req.BktName)) == nil {
+			resp.Status = StatusFail
+			resp.Msg = fmt.Sprintf("bucket %s not found", req.BktName)
+			return resp, nil
+		}
+		if tx.Bucket([]byte(req.NewBktName)) != nil {
+			resp.Status = StatusFail
+			resp.Msg = fmt.Sprintf("bucket %s already exists", req.NewBktName)
+			return resp, nil
+		}
+		err = copyBktAndDependents(tx, resp, req.BktName, req.NewBktName)
+		if err == nil && op == AdminRenameBkt {
+			err = dropBktAndDependents(tx, resp, req.BktName)
+		}
+	default:
+		resp.Status = StatusFail
+		resp.Msg = "Invalid BktAdmin Operation-" + op
+		return resp, nil
+	}
+	if err != nil {
+		log.Println("db error - bkt admin operation failed", req.Operation, req.BktName, err)
+		resp.Status = StatusFail
+		resp.Msg = "BktAdmin request failed-" + err.Error()
+		return resp, err // trans will be rolled back
+	}
+	if len(resp.Errs) > 0 {
+		resp.Status = StatusWarning
+		resp.Msg = "some dependents skipped, see resp.Errs for details"
+	} else {
+		resp.Status = StatusOk
+	}
+	return resp, nil
+}
+
+// getIndexSettings returns the IndexSettings from the index_settings bkt where DataBkt matches dataBkt.
+func getIndexSettings(tx *bolt.Tx, dataBkt string) ([]IndexSetting, error) {
+	settingsBkt := tx.Bucket([]byte(IndexSettingsBkt))
+	if settingsBkt == nil {
+		return nil, nil // no index settings
+	}
+	settings := make([]IndexSetting, 0, 5)
+	prefix := []byte(dataBkt)
+	csr := settingsBkt.Cursor()
+	for k, v := csr.Seek(prefix); k != nil && strings.HasPrefix(string(k), dataBkt); k, v = csr.Next() {
+		var setting IndexSetting
+		if err := json.Unmarshal(v, &setting); err != nil {
+			return nil, fmt.Errorf("error unmarshalling index setting for index bkt %s - %s", string(k), err.Error())
+		}
+		if setting.DataBkt != dataBkt {
+			continue // possible for prefix to match multiple data bkts, ex. "order" prefix matches "order", "order_item"
+		}
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+// deleteBkt deletes bkt if it exists and adds its name to resp.Recs.
+// If bkt does not exist, an ErrBktNotFound entry is added to resp.Errs.
+func deleteBkt(tx *bolt.Tx, resp *Response, bktName string) error {
+	if tx.Bucket([]byte(bktName)) == nil {
+		bErr := e(ErrBktNotFound, "bkt not found, skipped", []byte(bktName), nil)
+		resp.Errs = append(resp.Errs, *bErr)
+		return nil
+	}
+	if err := tx.DeleteBucket([]byte(bktName)); err != nil {
+		return fmt.Errorf("delete bkt %s failed - %s", bktName, err.Error())
+	}
+	resp.Recs = append(resp.Recs, []byte(bktName))
+	return nil
+}
+
+// dropIndex deletes the index bkt, its _inverted bkt and its index_settings entry.
+func dropIndex(tx *bolt.Tx, resp *Response, indexBkt string) error {
+	settingsBkt := tx.Bucket([]byte(IndexSettingsBkt))
+	if settingsBkt == nil || settingsBkt.Get([]byte(indexBkt)) == nil {
+		bErr := e(ErrIndexSetting, "index setting not found, skipped", []byte(indexBkt), nil)
+		resp.Errs = append(resp.Errs, *bErr)
+	} else if err := settingsBkt.Delete([]byte(indexBkt)); err != nil {
+		return fmt.Errorf("delete index setting %s failed - %s", indexBkt, err.Error())
+	}
+	if err := deleteBkt(tx, resp, indexBkt); err != nil {
+		return err
+	}
+	return deleteBkt(tx, resp, indexBkt+"_inverted")
+}
+
+// dropBktAndDependents deletes the data bkt, its indexes and its putlog bkt.
+func dropBktAndDependents(tx *bolt.Tx, resp *Response, bktName string) error {
+	settings, err := getIndexSettings(tx, bktName)
+	if err != nil {
+		return err
+	}
+	for _, setting := range settings {
+		if err = dropIndex(tx, resp, setting.IndexBkt); err != nil {
+			return err
+		}
+	}
+	if tx.Bucket([]byte(bktName+"_putlog")) != nil { // putlog bkt is optional, so not reported if missing
+		if err = deleteBkt(tx, resp, bktName+"_putlog"); err != nil {
+			return err
+		}
+	}
+	return deleteBkt(tx, resp, bktName)
+}
+
+// copyBkt copies all entries and the sequence # from one bkt to a new bkt.
+func copyBkt(tx *bolt.Tx, resp *Response, fromBktName, toBktName string) error {
+	fromBkt := tx.Bucket([]byte(fromBktName))
+	if fromBkt == nil {
+		bErr := e(ErrBktNotFound, "bkt not found, skipped", []byte(fromBktName), nil)
+		resp.Errs = append(resp.Errs, *bErr)
+		return nil
+	}
+	toBkt, err := tx.CreateBucket([]byte(toBktName))
+	if err != nil {
+		return fmt.Errorf("create bkt %s failed - %s", toBktName, err.Error())
+	}
+	err = fromBkt.ForEach(func(k, v []byte) error {
+		resp.PutCnt++
+		return toBkt.Put(k, v)
+	})
+	if err != nil {
+		return fmt.Errorf("copy bkt %s to %s failed - %s", fromBktName, toBktName, err.Error())
+	}
+	if err = toBkt.SetSequence(fromBkt.Sequence()); err != nil {
+		return fmt.Errorf("set sequence for bkt %s failed - %s", toBktName, err.Error())
+	}
+	resp.Recs = append(resp.Recs, []byte(toBktName))
+	return nil
+}
+
+// copyBktAndDependents copies the data bkt, its indexes and its putlog bkt.
+// A new IndexSetting is added for each copied index, with DataBkt and IndexBkt using the new bkt name.
+func copyBktAndDependents(tx *bolt.Tx, resp *Response, fromBktName, toBktName string) error {
+	settings, err := getIndexSettings(tx, fromBktName)
+	if err != nil {
+		return err
+	}
+	if err = copyBkt(tx, resp, fromBktName, toBktName); err != nil {
+		return err
+	}
+	if tx.Bucket([]byte(fromBktName+"_putlog")) != nil { // putlog bkt is optional, so not reported if missing
+		if err = copyBkt(tx, resp, fromBktName+"_putlog", toBktName+"_putlog"); err != nil {
+			return err
+		}
+	}
+	if len(settings) == 0 {
+		return nil
+	}
+	settingsBkt := tx.Bucket([]byte(IndexSettingsBkt))
+	for _, setting := range settings {
+		oldIndexBkt := setting.IndexBkt
+		setting.DataBkt = toBktName
+		setting.IndexBkt = toBktName + strings.TrimPrefix(oldIndexBkt, fromBktName)
+		if tx.Bucket([]byte(setting.IndexBkt)) != nil || settingsBkt.Get([]byte(setting.IndexBkt)) != nil {
+			return fmt.Errorf("index bkt %s already exists", setting.IndexBkt)
+		}
+		if err = copyBkt(tx, resp, oldIndexBkt, setting.IndexBkt); err != nil {
+			return err
+		}
+		if err = copyBkt(tx, resp, oldIndexBkt+"_inverted", setting.IndexBkt+"_inverted"); err != nil {
+			return err
+		}
+		val, err := json.Marshal(&setting)
+		if err != nil {
+			return fmt.Errorf("index setting json marshal error - %s", err.Error())
+		}
+		if err = settingsBkt.Put([]byte(setting.IndexBkt), val); err != nil {
+			return fmt.Errorf("index setting put error - %s", err.Error())
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ package bobb
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"strconv"
 	"strings"
@@ -122,6 +123,8 @@ type SearchKeysRequest struct {
 	StartKey    string
 	EndKey      string
 	Limit       int
+	Explain     bool // if true, Response.Stats contains execution statistics and plan, see stats.go
+	WithMeta    bool // if true, Response.Meta contains the key and value size of each rec, see RecMeta in types.go
 }
 
 func (req SearchKeysRequest) IsUpdtReq() bool {
@@ -136,6 +139,14 @@ func (req *SearchKeysRequest) Run(tx *bolt.Tx) (*Response, error) {
 	}
 	resp.Recs = make([][]byte, 0, InitialRespRecsSize)
 
+	var stats *ExecStats // nil unless Explain
+	if req.Explain {
+		stats = &ExecStats{Plan: rangePlan(req.BktName, "", req.StartKey, req.EndKey, false) + fmt.Sprintf("; key contains %q", req.SearchValue)}
+		resp.Stats = stats
+	}
+	start := stats.now()
+	keysScanned := 0
+
 	csr := bkt.Cursor()
 	var k, v []byte
 	if req.StartKey == "" {
@@ -156,14 +167,22 @@ func (req *SearchKeysRequest) Run(tx *bolt.Tx) (*Response, error) {
 		} else if req.EndKey != "" && string(k) > req.EndKey {
 			break
 		}
+		keysScanned++
 		if strings.Contains(string(k), req.SearchValue) {
 			resp.Recs = append(resp.Recs, v)
+			if req.WithMeta {
+				resp.Meta = append(resp.Meta, RecMeta{Key: string(k), Size: len(v)})
+			}
 			if len(resp.Recs) == req.Limit {
 				break
 			}
 		}
 		k, v = csr.Next()
 	}
+	if stats != nil {
+		stats.since("scan", start)
+		stats.KeysScanned, stats.RecsMatched = keysScanned, len(resp.Recs)
+	}
 	resp.Status = StatusOk
 	return resp, nil
 }
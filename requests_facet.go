@@ -0,0 +1,182 @@
+package bobb
+
+/*
+FacetRequest returns distinct values and counts for flds of the records matching a QryRequest.
+Counting is done in the QryRequest find loop (see facetCounter), so the range, Criteria,
+JoinsBeforeFind and Limit of the QryRequest apply, and records are read once.
+*/
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fastjson"
+	bolt "go.etcd.io/bbolt"
+)
+
+// FacetFld defines a fld to be counted by FacetRequest.
+type FacetFld struct {
+	Fld    string // fld name, nested flds use ".", ex. agent.name
+	SortBy string // see FacetSort* codes in codes.go, default FacetSortCount
+	Limit  int    // max # values returned, 0 means all
+}
+
+// FacetResult contains the distinct values of a FacetFld.
+// Strings are returned as is (no StrOption conversion), numbers and bools as json text, ex. "12", "true".
+// Array values are counted per element.
+type FacetResult struct {
+	Fld      string
+	Distinct int          // # of distinct values, before Limit applied
+	Missing  int          // # of matching recs where fld not found or null
+	Values   []ValueCount // see ValueCount in requests_index.go
+}
+
+// FacetRequest returns distinct values with counts for each of Facets.
+// Records counted are the records matching Qry before its Top and sort steps.
+// Note - Qry.Limit stops the read loop, so it also limits the records counted, use Qry.Top for page size.
+// If IncludeRecs is true, Response.Recs contains the Qry results (ex. first page), read in the same View trans.
+// Response.Rec contains json of []FacetResult, in Facets order. Response.GetCnt is from the Qry.
+type FacetRequest struct {
+	Qry         QryRequest // defines records to be counted
+	Facets      []FacetFld
+	IncludeRecs bool // if false, only counts are returned (Qry.CountOnly is set)
+}
+
+func (req FacetRequest) IsUpdtReq() bool {
+	return false
+}
+
+func (req *FacetRequest) Run(tx *bolt.Tx) (*Response, error) {
+
+	resp := new(Response)
+	if len(req.Facets) == 0 {
+		resp.Status = StatusFail
+		resp.Msg = "no Facets in request"
+		return resp, nil
+	}
+	counter := &facetCounter{flds: make([]FacetFld, len(req.Facets))}
+	for i, facet := range req.Facets {
+		if facet.Fld == "" {
+			resp.Status = StatusFail
+			resp.Msg = "FacetFld missing Fld"
+			return resp, nil
+		}
+		if facet.SortBy == "" {
+			facet.SortBy = FacetSortCount
+		}
+		if !slices.Contains(AllFacetSorts, facet.SortBy) {
+			resp.Status = StatusFail
+			resp.Msg = fmt.Sprintf("invalid FacetFld.SortBy: %s, for fld %s", facet.SortBy, facet.Fld)
+			return resp, nil
+		}
+		counter.flds[i] = facet
+	}
+	counter.init()
+
+	qry := req.Qry
+	qry.facets = counter
+	if !req.IncludeRecs {
+		qry.CountOnly = true
+	}
+	resp, err := qry.Run(tx)
+	if err != nil || resp.Status == StatusFail {
+		return resp, err
+	}
+	resp.Rec, err = json.Marshal(counter.results())
+	if err != nil {
+		resp.Status = StatusFail
+		resp.Msg = "facet results json marshal error - " + err.Error()
+		return resp, nil
+	}
+	return resp, nil
+}
+
+// facetCounter counts fld values, add is called by QryRequest.Run for each matching rec.
+type facetCounter struct {
+	flds    []FacetFld
+	paths   [][]string       // fld names split on "."
+	counts  []map[string]int // value:count for each fld
+	numeric []map[string]bool
+	missing []int
+}
+
+func (c *facetCounter) init() {
+	n := len(c.flds)
+	c.paths = make([][]string, n)
+	c.counts = make([]map[string]int, n)
+	c.numeric = make([]map[string]bool, n)
+	c.missing = make([]int, n)
+	for i, fld := range c.flds {
+		c.paths[i] = strings.Split(fld.Fld, ".")
+		c.counts[i] = make(map[string]int)
+		c.numeric[i] = make(map[string]bool)
+	}
+}
+
+func (c *facetCounter) add(parsedRec *fastjson.Value) {
+	for i, path := range c.paths {
+		val := parsedRec.Get(path...)
+		if val == nil || val.Type() == fastjson.TypeNull {
+			c.missing[i]++
+			continue
+		}
+		if val.Type() == fastjson.TypeArray {
+			for _, item := range val.GetArray() {
+				c.addValue(i, item)
+			}
+			continue
+		}
+		c.addValue(i, val)
+	}
+}
+
+func (c *facetCounter) addValue(i int, val *fastjson.Value) {
+	var strVal string
+	switch val.Type() {
+	case fastjson.TypeString:
+		strVal = string(val.GetStringBytes())
+	case fastjson.TypeNumber:
+		strVal = string(val.MarshalTo(nil))
+		c.numeric[i][strVal] = true
+	default:
+		strVal = string(val.MarshalTo(nil))
+	}
+	c.counts[i][strVal]++
+}
+
+func (c *facetCounter) results() []FacetResult {
+	results := make([]FacetResult, len(c.flds))
+	for i, fld := range c.flds {
+		values := make([]ValueCount, 0, len(c.counts[i]))
+		for val, count := range c.counts[i] {
+			values = append(values, ValueCount{Value: val, Count: count})
+		}
+		numeric := c.numeric[i]
+		compareValues := func(a, b ValueCount) int {
+			if numeric[a.Value] && numeric[b.Value] {
+				aNum, _ := strconv.ParseFloat(a.Value, 64)
+				bNum, _ := strconv.ParseFloat(b.Value, 64)
+				if n := cmp.Compare(aNum, bNum); n != 0 {
+					return n
+				}
+			}
+			return strings.Compare(a.Value, b.Value)
+		}
+		slices.SortFunc(values, func(a, b ValueCount) int {
+			if fld.SortBy == FacetSortCount && a.Count != b.Count {
+				return b.Count - a.Count
+			}
+			return compareValues(a, b)
+		})
+		results[i] = FacetResult{Fld: fld.Fld, Distinct: len(values), Missing: c.missing[i]}
+		if fld.Limit > 0 && len(values) > fld.Limit {
+			values = values[:fld.Limit]
+		}
+		results[i].Values = values
+	}
+	return results
+}
@@ -1,6 +1,13 @@
 package bobb
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/valyala/fastjson"
 	bolt "go.etcd.io/bbolt"
 )
 
@@ -9,12 +16,19 @@ type GetRequest struct {
 	BktName  string
 	Keys     []string // keys of records to be returned
 	ErrLimit int      // run stops when ErrLimit exceeded
+	Fields   []string // optional, return only these flds, see projection.go
+	WithMeta bool     // if true, Response.Meta contains the key and size of each rec, see RecMeta in types.go
+	Snapshot string   // optional, Id of open snapshot the request reads, see snapshot.go
 }
 
 func (req GetRequest) IsUpdtReq() bool {
 	return false
 }
 
+func (req GetRequest) SnapshotId() string {
+	return req.Snapshot
+}
+
 func (req *GetRequest) Run(tx *bolt.Tx) (*Response, error) {
 
 	resp := new(Response)
@@ -23,12 +37,34 @@ func (req *GetRequest) Run(tx *bolt.Tx) (*Response, error) {
 	if bkt == nil {
 		return resp, nil
 	}
+	proj, err := newProjection(req.Fields)
+	if err != nil {
+		resp.Status = StatusFail
+		resp.Msg = err.Error()
+		return resp, nil
+	}
+	var parser *fastjson.Parser // used by projection
+	if proj != nil {
+		parser = parserPool.Get()
+		defer parserPool.Put(parser)
+	}
 	resp.Recs = make([][]byte, 0, len(req.Keys))
+	if req.WithMeta {
+		resp.Meta = make([]RecMeta, 0, len(req.Keys))
+	}
 
 	for _, key := range req.Keys {
+		var bErr *BobbErr
 		v := bkt.Get([]byte(key))
+		size := len(v)
 		if v == nil {
-			bErr := e(ErrNotFound, "Key Not Found", []byte(key), nil)
+			bErr = e(ErrNotFound, "Key Not Found", []byte(key), nil)
+		} else if proj != nil {
+			if v, err = proj.applyBytes(parser, v); err != nil {
+				bErr = e(ErrParseRec, err.Error(), []byte(key), nil)
+			}
+		}
+		if bErr != nil {
 			resp.Errs = append(resp.Errs, *bErr)
 			if len(resp.Errs) > req.ErrLimit {
 				resp.Status = StatusFail
@@ -38,6 +74,9 @@ func (req *GetRequest) Run(tx *bolt.Tx) (*Response, error) {
 			continue
 		}
 		resp.Recs = append(resp.Recs, v)
+		if req.WithMeta {
+			resp.Meta = append(resp.Meta, RecMeta{Key: key, Size: size})
+		}
 	}
 	resp.GetCnt = len(resp.Recs)
 	if len(resp.Errs) > 0 {
@@ -49,24 +88,121 @@ func (req *GetRequest) Run(tx *bolt.Tx) (*Response, error) {
 	return resp, nil
 }
 
+// MultiGetRequest gets recs by key from any # of bkts, all read in 1 View trans, so results are consistent.
+// Response.Recs[i] is the rec of Items[i], nil if not found or ExistsOnly.
+// Response.Rec contains a json array of MultiGetResult, 1 per item in Items order.
+// Response.GetCnt is the # of items found. A missing key or bkt is reported in the item result, not Response.Errs.
+type MultiGetRequest struct {
+	Items      []MultiGetItem
+	ExistsOnly bool     // if true, recs are not returned, only item results (found and size)
+	Fields     []string // optional, return only these flds, see projection.go
+}
+
+// MultiGetItem is 1 rec of a MultiGetRequest.
+type MultiGetItem struct {
+	BktName string
+	Key     string
+}
+
+// MultiGetResult is the result of 1 MultiGetItem.
+type MultiGetResult struct {
+	Status string // StatusOk, ErrNotFound, ErrBktNotFound or ErrParseRec (projection failed)
+	Found  bool   // true if key is in bkt
+	Size   int    // size in bytes of the stored rec, 0 if not found
+}
+
+func (req MultiGetRequest) IsUpdtReq() bool {
+	return false
+}
+
+func (req *MultiGetRequest) Run(tx *bolt.Tx) (*Response, error) {
+
+	resp := new(Response)
+	if len(req.Items) == 0 {
+		resp.Status = StatusFail
+		resp.Msg = "no Items in request"
+		return resp, nil
+	}
+	proj, err := newProjection(req.Fields)
+	if err != nil {
+		resp.Status = StatusFail
+		resp.Msg = err.Error()
+		return resp, nil
+	}
+	var parser *fastjson.Parser // used by projection
+	if proj != nil && !req.ExistsOnly {
+		parser = parserPool.Get()
+		defer parserPool.Put(parser)
+	}
+	if !req.ExistsOnly {
+		resp.Recs = make([][]byte, len(req.Items))
+	}
+	results := make([]MultiGetResult, len(req.Items))
+	bkts := make(map[string]*bolt.Bucket) // opened bkts, nil if not found
+
+	for i, item := range req.Items {
+		bkt, opened := bkts[item.BktName]
+		if !opened {
+			bkt = tx.Bucket([]byte(item.BktName))
+			bkts[item.BktName] = bkt
+		}
+		if bkt == nil {
+			results[i].Status = ErrBktNotFound
+			continue
+		}
+		v := bkt.Get([]byte(item.Key))
+		if v == nil {
+			results[i].Status = ErrNotFound
+			continue
+		}
+		results[i] = MultiGetResult{Status: StatusOk, Found: true, Size: len(v)}
+		resp.GetCnt++
+		if req.ExistsOnly {
+			continue
+		}
+		if proj != nil {
+			if v, err = proj.applyBytes(parser, v); err != nil {
+				results[i].Status = ErrParseRec
+				continue
+			}
+		}
+		resp.Recs[i] = v
+	}
+	if resp.Rec, err = json.Marshal(results); err != nil {
+		return resp, err
+	}
+	resp.Status = StatusOk
+	return resp, nil
+}
+
 // GetAllRequest returns all records in bucket or records in range between Start/End keys.
 // Records are returned in key order.
 // If StartKey == EndKey, rec key prefix must match StartKey.
 // If StartKey = "", reads from beginning. If EndKey = "" reads to end.
 // If end of bkt not reached, response.NextKey will be next key in order.
+// If Reverse, records are returned in descending key order, starting with EndKey.
 type GetAllRequest struct {
 	BktName  string
-	IndexBkt string // name of bkt used as index
-	StartKey string // if not "", keys >= this value
-	EndKey   string // if not "", keys <= this value
-	Limit    int    // max # recs to return
-	ErrLimit int    // run stops when ErrLimit exceeded, default 0, settings.MaxErrs limit if -1
+	IndexBkt string   // name of bkt used as index
+	StartKey string   // if not "", keys >= this value
+	EndKey   string   // if not "", keys <= this value
+	Limit    int      // max # recs to return
+	ErrLimit int      // run stops when ErrLimit exceeded, default 0, settings.MaxErrs limit if -1
+	Reverse  bool     // if true, read keys in descending order, use resp.NextKey as EndKey for next page
+	Fields   []string // optional, return only these flds, see projection.go
+	Explain  bool     // if true, Response.Stats contains execution statistics and plan, see stats.go
+	WithMeta bool     // if true, Response.Meta contains the key, index key and size of each rec, see RecMeta in types.go
+	Snapshot string   // optional, Id of open snapshot the request reads, see snapshot.go
 }
 
 func (req GetAllRequest) IsUpdtReq() bool {
 	return false
 }
 
+func (req GetAllRequest) SnapshotId() string {
+	return req.Snapshot
+}
+
 func (req *GetAllRequest) Run(tx *bolt.Tx) (*Response, error) {
 	resp := new(Response)
 	bkt := openBkt(tx, resp, req.BktName)
@@ -75,6 +211,11 @@ func (req *GetAllRequest) Run(tx *bolt.Tx) (*Response, error) {
 	}
 	var index *bolt.Bucket
 	if req.IndexBkt != "" {
+		if err := notTextIndex(tx, req.IndexBkt); err != nil {
+			resp.Status = StatusFail
+			resp.Msg = err.Error()
+			return resp, nil
+		}
 		index = openBkt(tx, resp, req.IndexBkt)
 		if index == nil {
 			return resp, nil
@@ -83,12 +224,39 @@ func (req *GetAllRequest) Run(tx *bolt.Tx) (*Response, error) {
 	if req.ErrLimit == -1 { // see server/bobb_settings.json for MaxErrs value (defined in util.go)
 		req.ErrLimit = MaxErrs
 	}
+	proj, err := newProjection(req.Fields)
+	if err != nil {
+		resp.Status = StatusFail
+		resp.Msg = err.Error()
+		return resp, nil
+	}
+	var parser *fastjson.Parser // used by projection
+	if proj != nil {
+		parser = parserPool.Get()
+		defer parserPool.Put(parser)
+	}
 	resp.Recs = make([][]byte, 0, InitialRespRecsSize)
+	if req.WithMeta {
+		resp.Meta = make([]RecMeta, 0, InitialRespRecsSize)
+	}
+
+	var stats *ExecStats // nil unless Explain
+	if req.Explain {
+		stats = &ExecStats{Plan: rangePlan(req.BktName, req.IndexBkt, req.StartKey, req.EndKey, req.Reverse)}
+		if proj != nil {
+			stats.Plan += fmt.Sprintf("; project %d flds", len(proj.flds))
+		}
+		resp.Stats = stats
+	}
+	var keysScanned, recsParsed int // copied to stats at end
+	start := stats.now()
+	var t time.Time
 
 	var k, v []byte
 	var bErr *BobbErr
 
 	readLoop := NewReadLoop(bkt, index)
+	readLoop.Reverse = req.Reverse
 	k, v, bErr = readLoop.Start(req.StartKey, req.EndKey, req.Limit)
 	if bErr != nil {
 		resp.Errs = append(resp.Errs, *bErr)
@@ -97,19 +265,46 @@ func (req *GetAllRequest) Run(tx *bolt.Tx) (*Response, error) {
 
 	for k != nil {
 		if len(resp.Errs) > req.ErrLimit {
-			resp.Status = StatusFail
-			resp.Msg = "too many errors, see resp.Errs for details"
-			return resp, nil
+			break
 		}
+		keysScanned++
 		if bErr != nil { // triggered when readLoop returns errCode
 			resp.Errs = append(resp.Errs, *bErr)
 			k, v, bErr = readLoop.Next()
 			continue
 		}
+		size := len(v)
+		if proj != nil {
+			t = stats.now()
+			v, err = proj.applyBytes(parser, v)
+			stats.since("project", t)
+			if err != nil {
+				resp.Errs = append(resp.Errs, *e(ErrParseRec, err.Error(), k, nil))
+				k, v, bErr = readLoop.Next()
+				continue
+			}
+			recsParsed++
+		}
 		resp.Recs = append(resp.Recs, v)
+		if req.WithMeta {
+			resp.Meta = append(resp.Meta, readLoop.recMeta(k, size))
+		}
 		readLoop.Count++
 		k, v, bErr = readLoop.Next()
 	}
+	if stats != nil {
+		stats.since("scan", start)
+		stats.KeysScanned, stats.RecsParsed = keysScanned, recsParsed
+		stats.RecsMatched, stats.ErrsSkipped = len(resp.Recs), len(resp.Errs)
+		if readLoop.UsingIndex {
+			stats.IndexLookups = keysScanned
+		}
+	}
+	if len(resp.Errs) > req.ErrLimit {
+		resp.Status = StatusFail
+		resp.Msg = "too many errors, see resp.Errs for details"
+		return resp, nil
+	}
 	if readLoop.NextKey != nil { // ReadLoop.NextKey is loaded by Next() at end of range.
 		resp.NextKey = string(readLoop.NextKey)
 	}
@@ -132,6 +327,7 @@ type GetAllKeysRequest struct {
 	StartKey string // if not "", keys >= this value
 	EndKey   string // if not "", keys <= this value
 	Limit    int    // max # recs to return
+	Reverse  bool   // if true, read keys in descending order, use resp.NextKey as EndKey for next page
 }
 
 func (req GetAllKeysRequest) IsUpdtReq() bool {
@@ -145,8 +341,10 @@ func (req *GetAllKeysRequest) Run(tx *bolt.Tx) (*Response, error) {
 		return resp, nil
 	}
 	resp.Recs = make([][]byte, 0, InitialRespRecsSize)
+	resp.keyRecs = true
 
 	readLoop := NewReadLoop(bkt, nil)
+	readLoop.Reverse = req.Reverse
 	k, _, _ := readLoop.Start(req.StartKey, req.EndKey, req.Limit)
 	for k != nil {
 		resp.Recs = append(resp.Recs, k)
@@ -161,6 +359,94 @@ func (req *GetAllKeysRequest) Run(tx *bolt.Tx) (*Response, error) {
 	return resp, nil
 }
 
+// ListKeysRequest lists the keys of a bkt as a hierarchy, like a file system, ex. keys "client|date|seq".
+// Keys beginning with Prefix are listed. If the rest of a key contains Delimiter, the key is grouped under
+// the common prefix ending with the 1st Delimiter after Prefix, else the key itself is listed.
+// Ex. Prefix "acme|", Delimiter "|" lists prefixes "acme|2024-01-01|", "acme|2024-01-02|", ... with key counts.
+// Response.Rec contains a KeyListing, entries (prefixes and keys) are in key order.
+// If Limit is hit, Response.NextKey is the Cursor of the next page.
+type ListKeysRequest struct {
+	BktName   string
+	Prefix    string // only keys beginning with Prefix, all keys if ""
+	Delimiter string // separates key levels, if "" all keys are listed
+	Cursor    string // continue listing at this key, use resp.NextKey of previous page
+	Limit     int    // max # of entries (prefixes + keys) returned, 0 means no limit
+	NoCounts  bool   // if true, prefix counts are not loaded and keys of each prefix are skipped with 1 seek, faster for large bkts
+}
+
+// KeyListing is returned in Response.Rec by ListKeysRequest.
+type KeyListing struct {
+	Prefixes []KeyPrefix
+	Keys     []string // keys without Delimiter after Prefix
+}
+
+// KeyPrefix is a common prefix of KeyListing.
+type KeyPrefix struct {
+	Prefix string // ends with Delimiter
+	Count  int    // # of keys beginning with Prefix, 0 if NoCounts
+}
+
+func (req ListKeysRequest) IsUpdtReq() bool {
+	return false
+}
+
+func (req *ListKeysRequest) Run(tx *bolt.Tx) (*Response, error) {
+	resp := new(Response)
+	bkt := openBkt(tx, resp, req.BktName)
+	if bkt == nil {
+		return resp, nil
+	}
+	listing := KeyListing{Prefixes: []KeyPrefix{}, Keys: []string{}}
+	prefix := []byte(req.Prefix)
+	delim := []byte(req.Delimiter)
+
+	seekKey := prefix
+	if req.Cursor > req.Prefix {
+		seekKey = []byte(req.Cursor)
+	}
+	csr := bkt.Cursor()
+	k, _ := csr.Seek(seekKey)
+	entries := 0
+	for k != nil && bytes.HasPrefix(k, prefix) {
+		if req.Limit > 0 && entries == req.Limit {
+			resp.NextKey = string(k)
+			break
+		}
+		entries++
+		i := -1
+		if len(delim) > 0 {
+			i = bytes.Index(k[len(prefix):], delim)
+		}
+		if i < 0 {
+			listing.Keys = append(listing.Keys, string(k))
+			k, _ = csr.Next()
+			continue
+		}
+		group := slices.Clone(k[:len(prefix)+i+len(delim)])
+		if req.NoCounts {
+			listing.Prefixes = append(listing.Prefixes, KeyPrefix{Prefix: string(group)})
+			if end := prefixEnd(group); end != nil { // see readloop.go
+				k, _ = csr.Seek(end)
+			} else {
+				k = nil
+			}
+			continue
+		}
+		count := 0
+		for ; k != nil && bytes.HasPrefix(k, group); k, _ = csr.Next() {
+			count++
+		}
+		listing.Prefixes = append(listing.Prefixes, KeyPrefix{Prefix: string(group), Count: count})
+	}
+	var err error
+	if resp.Rec, err = json.Marshal(listing); err != nil {
+		return resp, err
+	}
+	resp.GetCnt = entries
+	resp.Status = StatusOk
+	return resp, nil
+}
+
 // GetOneRequest is used to get a specific record by Key.
 type GetOneRequest struct {
 	BktName string
@@ -190,3 +476,63 @@ func (req *GetOneRequest) Run(tx *bolt.Tx) (*Response, error) {
 	resp.Status = StatusOk
 	return resp, nil
 }
+
+// GetByIndexRequest returns data records through an index using logical field values.
+// The IndexSetting for IndexBkt defines how Conditions are encoded into index keys,
+// so clients do not need to know about padding, separators, StrOption or key suffixes.
+// Conditions[i] applies to IndexSetting.KeyFlds[i], see IndexCondition in requests_index.go.
+// Leading KeyFlds can be omitted from the end, ex. 1 condition for a city|st index matches all sts for the city.
+// Records are returned in index key order, the same as GetAllRequest with IndexBkt.
+type GetByIndexRequest struct {
+	IndexBkt   string           // name of index bkt, must have entry in index_settings bkt
+	Conditions []IndexCondition // logical values for KeyFlds, in KeyFlds order
+	Limit      int              // max # recs to return
+	ErrLimit   int              // run stops when ErrLimit exceeded, default 0, settings.MaxErrs limit if -1
+	Reverse    bool             // if true, read index keys in descending order
+	Fields     []string         // optional, return only these flds, see projection.go
+	WithMeta   bool             // if true, Response.Meta contains the key, index key and size of each rec, see RecMeta in types.go
+}
+
+func (req GetByIndexRequest) IsUpdtReq() bool {
+	return false
+}
+
+func (req *GetByIndexRequest) Run(tx *bolt.Tx) (*Response, error) {
+	resp := new(Response)
+	if req.IndexBkt == "" {
+		resp.Status = StatusFail
+		resp.Msg = "IndexBkt not specified in request"
+		return resp, nil
+	}
+	setting, err := getIndexSetting(tx, req.IndexBkt)
+	if err != nil {
+		resp.Status = StatusFail
+		resp.Msg = err.Error()
+		return resp, nil
+	}
+	if setting.IndexType == IndexTypeText {
+		resp.Status = StatusFail
+		resp.Msg = fmt.Sprintf("index %s is a text index, use TextSearchRequest", req.IndexBkt)
+		return resp, nil
+	}
+	startKey, endKey, err := indexKeyRange(setting, req.Conditions)
+	if err != nil {
+		resp.Status = StatusFail
+		resp.Msg = "invalid Conditions - " + err.Error()
+		return resp, nil
+	}
+	Trace(fmt.Sprintf("GetByIndex %s, start key %q, end key %q", req.IndexBkt, startKey, endKey))
+
+	getAllReq := GetAllRequest{
+		BktName:  setting.DataBkt,
+		IndexBkt: req.IndexBkt,
+		StartKey: startKey,
+		EndKey:   endKey,
+		Limit:    req.Limit,
+		ErrLimit: req.ErrLimit,
+		Reverse:  req.Reverse,
+		Fields:   req.Fields,
+		WithMeta: req.WithMeta,
+	}
+	return getAllReq.Run(tx)
+}
@@ -5,11 +5,14 @@ Request types for managing indexes in Bobb.
 IndexSettingRequest - load index settings into index_settings bkt
 IndexRequest - add index entries for specific data keys, keys in a range, or all keys in a data bkt
 VerifyIndexRequest - verify index entries are valid
+IndexStatsRequest - key count, size, key component distribution and split keys for an index (or any) bkt
+GetByIndexRequest (see requests_get.go) uses IndexCondition to read records through an index
 */
 
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"slices"
 	"strconv"
 	"strings"
@@ -29,6 +32,9 @@ IndexSetting values, which are stored in the database, are used by the Indexr.
 // KeySuffixWidth is used to pad the index key suffix to fixed width with leading zeros.
 // This ensures proper sorting of index keys.
 // Example - if KeySuffixWidth is 6, index keys will end with suffixes like "000001", "000002", ..., "000010", etc.
+//
+// IndexType IndexTypeText creates a text index, words from TextFlds are index keys, used by TextSearchRequest.
+// KeyFlds and KeySuffixWidth are not used by text indexes.
 type IndexSetting struct {
 	DataBkt        string      // name of data bkt, ex. "inquiry"
 	IndexBkt       string      // name of index bkt, must begin with value of DataBkt and end with "_index", ex. "inquiry_timestamp_index"
@@ -36,6 +42,8 @@ type IndexSetting struct {
 	FldSeparator   string      // optional separator used in merged field values, ex. "|" > "critical   |00033|temp high     "
 	KeySuffixWidth int         // using IndexBkt nextSeq# add numeric suffix to index key, 0 means use KeySuffixWidth from bobb_setting.json, -1 no suffix
 	SkipOnErr      bool        // if true, if error creating/updating index entry for a data rec, skip and do not fail entire PutRequest
+	IndexType      string      // IndexTypeKey (default) or IndexTypeText, see codes.go
+	TextFlds       []string    // IndexTypeText only, string flds split into words for text index, see requests_text.go
 }
 
 // IndexSettingRequest loads IndexSettings into the "index_settings" bkt.
@@ -69,10 +77,24 @@ func (req *IndexSettingRequest) Run(tx *bolt.Tx) (*Response, error) {
 			resp.Msg = "IndexSetting IndexBkt must begin with DataBkt and end with _index"
 			return resp, ErrBadInputData // trans will rollback
 		}
+		if setting.IndexType != "" && !slices.Contains(AllIndexTypes, setting.IndexType) {
+			resp.Status = StatusFail
+			resp.Msg = fmt.Sprintf("IndexSetting has invalid IndexType: %s, must be bobb.IndexTypeKey or bobb.IndexTypeText", setting.IndexType)
+			return resp, ErrBadInputData // trans will rollback
+		}
+		if setting.IndexType == IndexTypeText {
+			if len(setting.TextFlds) == 0 {
+				resp.Status = StatusFail
+				resp.Msg = "IndexSetting with IndexTypeText must have TextFlds"
+				return resp, ErrBadInputData // trans will rollback
+			}
+			setting.KeyFlds = nil
+			setting.KeySuffixWidth = -1 // text index keys are word|datakey, so no suffix needed
+		}
 		if setting.KeySuffixWidth == 0 {
 			setting.KeySuffixWidth = KeySuffixWidth // use global KeySuffixWidth, set at startup by bobb_server.go from bobb_settings.json
 		}
-		if setting.KeySuffixWidth == -1 && len(setting.KeyFlds) == 0 {
+		if setting.KeySuffixWidth == -1 && len(setting.KeyFlds) == 0 && setting.IndexType != IndexTypeText {
 			resp.Status = StatusFail
 			resp.Msg = "IndexSetting must have KeyFlds and/or KeySuffixWidth > -1"
 			return resp, ErrBadInputData // trans will rollback
@@ -81,24 +103,9 @@ func (req *IndexSettingRequest) Run(tx *bolt.Tx) (*Response, error) {
 			setting.KeySuffixWidth = 0
 		}
 		for _, fld := range setting.KeyFlds {
-			if fld.FldName == "" || fld.FldType == "" || fld.Length <= 0 {
-				resp.Status = StatusFail
-				resp.Msg = "IndexSetting KeyFlds must have FldName, FldType and Length > 0"
-				return resp, ErrBadInputData // trans will rollback
-			}
-			if fld.FldType != FldTypeStr && fld.FldType != FldTypeInt {
-				resp.Status = StatusFail
-				resp.Msg = fmt.Sprintf("IndexSetting KeyFlds have invalid FldType: %s, must be bobb.FldTypeStr('string') or bobb.FldTypeInt('int'), fld %s", fld.FldType, fld.FldName)
-				return resp, ErrBadInputData // trans will rollback
-			}
-			if fld.FldType == FldTypeStr && !slices.Contains(AllStrOptions, fld.StrOption) {
+			if err := validateFldFormat(fld, "IndexSetting KeyFlds"); err != nil {
 				resp.Status = StatusFail
-				resp.Msg = fmt.Sprintf("IndexSetting KeyFlds with FldTypeStr have invalid StrOption: %s, must be one of bobb.StrPlain, bobb.StrLowerCase, bobb.StrAsIs, fld %s", fld.StrOption, fld.FldName)
-				return resp, ErrBadInputData // trans will rollback
-			}
-			if !slices.Contains(AllDefaultCodes, fld.UseDefault) {
-				resp.Status = StatusFail
-				resp.Msg = fmt.Sprintf("IndexSetting KeyFlds have invalid UseDefault: %s, must be one of bobb.DefaultAlways, bobb.DefaultNever, bobb.DefaultIsNull, bobb.DefaultNotFound, fld %s", fld.UseDefault, fld.FldName)
+				resp.Msg = err.Error()
 				return resp, ErrBadInputData // trans will rollback
 			}
 		}
@@ -121,6 +128,29 @@ func (req *IndexSettingRequest) Run(tx *bolt.Tx) (*Response, error) {
 	return resp, nil
 }
 
+// validateFldFormat checks fld is a valid index key fld, desc begins error messages, ex. "IndexSetting KeyFlds".
+func validateFldFormat(fld FldFormat, desc string) error {
+	if fld.FldName == "" || fld.FldType == "" || fld.Length <= 0 {
+		return fmt.Errorf("%s must have FldName, FldType and Length > 0", desc)
+	}
+	if fld.FldType != FldTypeStr && fld.FldType != FldTypeInt {
+		return fmt.Errorf("%s have invalid FldType: %s, must be bobb.FldTypeStr('string') or bobb.FldTypeInt('int'), fld %s", desc, fld.FldType, fld.FldName)
+	}
+	if fld.FldType == FldTypeStr && !slices.Contains(AllStrOptions, fld.StrOption) {
+		return fmt.Errorf("%s with FldTypeStr have invalid StrOption: %s, must be one of bobb.StrPlain, bobb.StrLowerCase, bobb.StrAsIs, fld %s", desc, fld.StrOption, fld.FldName)
+	}
+	if fld.Transform != "" && (fld.FldType != FldTypeStr || !slices.Contains(AllTransforms, fld.Transform)) {
+		return fmt.Errorf("%s have invalid Transform: %s, must be one of bobb.TransformDay, bobb.TransformMonth, bobb.TransformPrefix for FldTypeStr, fld %s", desc, fld.Transform, fld.FldName)
+	}
+	if fld.Transform == TransformPrefix && fld.PrefixLen <= 0 {
+		return fmt.Errorf("%s with TransformPrefix must have PrefixLen > 0, fld %s", desc, fld.FldName)
+	}
+	if !slices.Contains(AllDefaultCodes, fld.UseDefault) {
+		return fmt.Errorf("%s have invalid UseDefault: %s, must be one of bobb.DefaultAlways, bobb.DefaultNever, bobb.DefaultIsNull, bobb.DefaultNotFound, fld %s", desc, fld.UseDefault, fld.FldName)
+	}
+	return nil
+}
+
 // IndexRequest is used to add index records for specific data keys, keys in a range, or all keys in a data bkt.
 // Only one indexing mode can be used per request:
 //   - IndexAll: true — index all records in DataBkt
@@ -128,6 +158,7 @@ func (req *IndexSettingRequest) Run(tx *bolt.Tx) (*Response, error) {
 //   - DataKeys — index specific records by key
 //
 // Errors are collected in resp.Errs until SkipOnErrLimit is exceeded.
+// If IndexBkt is a text index (see requests_text.go), its IndexSetting defines the entries and MergeFlds must be empty.
 // Warning - if there is potential for the result of MergeFlds to not be unique, a KeySuffix is required
 type IndexRequest struct {
 	DataBkt        string      // name of data bkt, DataKeys refer to this bkt
@@ -150,6 +181,33 @@ func (req *IndexRequest) Run(tx *bolt.Tx) (*Response, error) {
 
 	resp := new(Response)
 
+	for _, fld := range req.MergeFlds {
+		if fld.StrOption == "" { // same defaults as MergeFlds
+			fld.StrOption = StrLowerCase
+		}
+		if fld.UseDefault == "" {
+			fld.UseDefault = DefaultAlways
+		}
+		if err := validateFldFormat(fld, "IndexRequest MergeFlds"); err != nil {
+			resp.Status = StatusFail
+			resp.Msg = err.Error()
+			return resp, nil
+		}
+	}
+	// text index, entries are built by Indexr.runText from the IndexSetting instead of MergeFlds
+	var textIndexr *Indexr
+	if setting, err := getIndexSetting(tx, req.IndexBkt); err == nil && setting.IndexType == IndexTypeText {
+		if len(req.MergeFlds) > 0 || setting.DataBkt != req.DataBkt {
+			resp.Status = StatusFail
+			resp.Msg = fmt.Sprintf("IndexRequest for text index %s must have DataBkt %s and no MergeFlds", req.IndexBkt, setting.DataBkt)
+			return resp, nil
+		}
+		if textIndexr, err = NewIndxr(tx, setting); err != nil {
+			resp.Status = StatusFail
+			resp.Msg = err.Error()
+			return resp, nil
+		}
+	}
 	dataBkt := openBkt(tx, resp, req.DataBkt)
 	if dataBkt == nil {
 		return resp, nil
@@ -180,6 +238,13 @@ func (req *IndexRequest) Run(tx *bolt.Tx) (*Response, error) {
 		if err != nil {
 			return e(ErrParseRec, err.Error(), k, v)
 		}
+		if textIndexr != nil {
+			if err = textIndexr.runText(k, parsedRec, IndexingNormal); err != nil {
+				return e("Index Error", err.Error(), k, v)
+			}
+			resp.PutCnt++
+			return nil
+		}
 		indexKey, err := MergeFlds(parsedRec, req.MergeFlds, req.FldSeparator)
 		if err != nil {
 			return e("Index MergeFlds Error", err.Error(), k, v)
@@ -291,6 +356,11 @@ func (req VerifyIndexRequest) IsUpdtReq() bool {
 func (req *VerifyIndexRequest) Run(tx *bolt.Tx) (*Response, error) {
 
 	resp := new(Response)
+	if err := notTextIndex(tx, req.IndexBkt); err != nil {
+		resp.Status = StatusFail
+		resp.Msg = err.Error()
+		return resp, nil
+	}
 	dataBkt := openBkt(tx, resp, req.DataBkt)
 	if dataBkt == nil {
 		return resp, nil
@@ -358,3 +428,335 @@ func (req *VerifyIndexRequest) Run(tx *bolt.Tx) (*Response, error) {
 	}
 	return resp, nil
 }
+
+// IndexCondition supplies a logical value for one IndexSetting KeyFld, used by GetByIndexRequest.
+// Values are encoded the same way MergeFlds encodes record values (StrOption, Transform, padding, leading zeros, Desc).
+// For IndexRange on a Desc key fld, ValStr/ValInt and ToStr/ToInt can be given in either order.
+type IndexCondition struct {
+	Op     string // see Index* op codes in codes.go, default IndexEquals
+	ValStr string // for FldTypeStr key flds, also prefix value for IndexPrefix
+	ValInt int    // for FldTypeInt key flds
+	ToStr  string // IndexRange end value for FldTypeStr key flds
+	ToInt  int    // IndexRange end value for FldTypeInt key flds
+}
+
+// getIndexSetting returns the IndexSetting stored in the index_settings bkt for indexBkt.
+func getIndexSetting(tx *bolt.Tx, indexBkt string) (*IndexSetting, error) {
+	settingsBkt := tx.Bucket([]byte(IndexSettingsBkt))
+	if settingsBkt == nil {
+		return nil, fmt.Errorf("index setting not found for %s, no index_settings bkt", indexBkt)
+	}
+	v := settingsBkt.Get([]byte(indexBkt))
+	if v == nil {
+		return nil, fmt.Errorf("index setting not found for %s", indexBkt)
+	}
+	setting := new(IndexSetting)
+	if err := json.Unmarshal(v, setting); err != nil {
+		return nil, fmt.Errorf("error unmarshalling index setting for index bkt %s - %s", indexBkt, err.Error())
+	}
+	return setting, nil
+}
+
+// indexKeyRange converts conditions into start/end keys for the index defined by setting.
+// Conditions[i] applies to setting.KeyFlds[i]. Only the last condition can be IndexRange or IndexPrefix.
+// Returned keys follow ReadLoop rules, if startKey == endKey, index keys must begin with startKey.
+func indexKeyRange(setting *IndexSetting, conditions []IndexCondition) (startKey, endKey string, err error) {
+	if len(conditions) > len(setting.KeyFlds) {
+		return "", "", fmt.Errorf("index %s has %d KeyFlds, request has %d conditions", setting.IndexBkt, len(setting.KeyFlds), len(conditions))
+	}
+	if len(conditions) == 0 {
+		return "", "", nil // all index entries
+	}
+	var prefix strings.Builder
+	for i, condition := range conditions {
+		fld := setting.KeyFlds[i]
+		if condition.Op == "" {
+			condition.Op = IndexEquals
+		}
+		if !slices.Contains(AllIndexOps, condition.Op) {
+			return "", "", fmt.Errorf("invalid IndexCondition op %s for fld %s", condition.Op, fld.FldName)
+		}
+		if condition.Op != IndexEquals && i < len(conditions)-1 {
+			return "", "", fmt.Errorf("only the last IndexCondition can use op %s, fld %s", condition.Op, fld.FldName)
+		}
+		if i > 0 {
+			prefix.WriteString(setting.FldSeparator)
+		}
+		switch condition.Op {
+		case IndexEquals:
+			val, err := FormatFldVal(fld, condition.ValStr, condition.ValInt)
+			if err != nil {
+				return "", "", err
+			}
+			prefix.WriteString(val)
+		case IndexPrefix:
+			if fld.FldType != FldTypeStr {
+				return "", "", fmt.Errorf("IndexPrefix requires string key fld, fld %s", fld.FldName)
+			}
+			if fld.Transform != TransformPrefix {
+				fld.Transform = "" // date transforms require full date, prefix of date value is used as is
+			}
+			val, err := convertStr(fld, condition.ValStr) // not padded
+			if err != nil {
+				return "", "", err
+			}
+			if fld.Desc {
+				val = descStr(val) // chars are inverted individually, so prefix is still a prefix
+			}
+			prefix.WriteString(val)
+			return prefix.String(), prefix.String(), nil // FldSeparator not added, val is not padded
+		case IndexRange:
+			from, err := FormatFldVal(fld, condition.ValStr, condition.ValInt)
+			if err != nil {
+				return "", "", err
+			}
+			to, err := FormatFldVal(fld, condition.ToStr, condition.ToInt)
+			if err != nil {
+				return "", "", err
+			}
+			if from > to { // Desc key flds invert order
+				from, to = to, from
+			}
+			// "\xff" makes end key greater than any index key beginning with the end value (fld values are fixed length)
+			return prefix.String() + from, prefix.String() + to + "\xff", nil
+		}
+	}
+	if len(conditions) < len(setting.KeyFlds) || setting.KeySuffixWidth > 0 {
+		prefix.WriteString(setting.FldSeparator) // more key components follow
+	}
+	return prefix.String(), prefix.String(), nil
+}
+
+// IndexStats is returned in Response.Rec (json marshaled) by IndexStatsRequest.
+type IndexStats struct {
+	BktName    string           // bkt stats were collected for
+	KeyCount   int              // # of entries in bkt
+	Depth      int              // # of levels in bkt B+tree, from bkt.Stats()
+	SizeInuse  int              // approx bytes used by bkt entries, from bkt.Stats()
+	SizeAlloc  int              // approx bytes allocated to bkt pages, from bkt.Stats()
+	Components []ComponentStats // key component distribution, see IndexStatsRequest
+	SplitKeys  []string         // 1st key of each partition
+	Partitions []KeyRange       // evenly sized key ranges, usable as StartKey/EndKey
+}
+
+// ComponentStats describes the distribution of one key component.
+// Component i of key "a|b|c" with separator "|" is the i'th value, its merged prefix is all values up to and including it.
+type ComponentStats struct {
+	Name             string       // FldName from IndexSetting KeyFlds, or component # if no IndexSetting
+	DistinctPrefixes int          // # of distinct merged key prefixes ending with this component
+	TopValues        []ValueCount // most frequent component values, highest count first, Desc flds are decoded
+	Approx           bool         // component has more than maxStatsValues distinct values, TopValues counts are lower bounds
+}
+
+// maxStatsValues limits the # of distinct values counted per component by IndexStatsRequest,
+// so memory does not grow with bkt size when a component is unique per key (ex. last component with FldSeparator).
+const maxStatsValues = 10000
+
+// valueCounter counts values, keeping at most maxStatsValues counts (Misra-Gries frequent items).
+// When full, a new value decrements every count and values reaching 0 are dropped,
+// so counts are exact until the 1st overflow and lower bounds after, frequent values are kept.
+type valueCounter struct {
+	counts map[string]int
+	approx bool
+}
+
+func (c *valueCounter) add(val string) {
+	if _, found := c.counts[val]; found || len(c.counts) < maxStatsValues {
+		c.counts[val]++
+		return
+	}
+	c.approx = true
+	for v, n := range c.counts {
+		if n == 1 {
+			delete(c.counts, v)
+		} else {
+			c.counts[v] = n - 1
+		}
+	}
+}
+
+// ValueCount is a value and the # of times it occurs.
+type ValueCount struct {
+	Value string
+	Count int
+}
+
+// KeyRange is an inclusive range of keys, Count is # of keys in range.
+type KeyRange struct {
+	StartKey string
+	EndKey   string
+	Count    int
+}
+
+// IndexStatsRequest returns statistics about an index bkt, or any bkt, in Response.Rec (see IndexStats type).
+// Keys are split into components using the IndexSetting for BktName (KeyFlds lengths and FldSeparator).
+// If BktName has no IndexSetting, keys are split using FldSeparator. If neither, Components is empty.
+// If Partitions > 0, the keys are divided into that many evenly sized ranges (sizes differ by at most 1).
+type IndexStatsRequest struct {
+	BktName      string // index bkt or any bkt
+	FldSeparator string // used to split keys into components if BktName has no IndexSetting
+	TopValues    int    // max # of most frequent values returned per component, default 10
+	Partitions   int    // # of key ranges returned in IndexStats.Partitions, 0 means none
+}
+
+func (req IndexStatsRequest) IsUpdtReq() bool {
+	return false
+}
+
+func (req *IndexStatsRequest) Run(tx *bolt.Tx) (*Response, error) {
+
+	resp := new(Response)
+	bkt := openBkt(tx, resp, req.BktName)
+	if bkt == nil {
+		return resp, nil
+	}
+	if req.TopValues == 0 {
+		req.TopValues = 10
+	}
+	bktStats := bkt.Stats()
+	pageSize := tx.DB().Info().PageSize
+	stats := IndexStats{
+		BktName:   req.BktName,
+		KeyCount:  bktStats.KeyN,
+		Depth:     bktStats.Depth,
+		SizeInuse: bktStats.BranchInuse + bktStats.LeafInuse,
+		SizeAlloc: (bktStats.BranchPageN + bktStats.BranchOverflowN + bktStats.LeafPageN + bktStats.LeafOverflowN) * pageSize,
+	}
+
+	// componentEnds returns offset in key where each component ends
+	var componentEnds func(key string) []int
+	var names []string
+	var desc []bool // component is a Desc KeyFld
+
+	setting, _ := getIndexSetting(tx, req.BktName) // no setting is not an error
+	switch {
+	case setting != nil && len(setting.KeyFlds) > 0:
+		ends := make([]int, len(setting.KeyFlds))
+		offset := 0
+		for i, fld := range setting.KeyFlds {
+			names = append(names, fld.FldName)
+			desc = append(desc, fld.Desc)
+			offset += fld.Length
+			ends[i] = offset
+			offset += len(setting.FldSeparator)
+		}
+		componentEnds = func(key string) []int {
+			n := 0
+			for n < len(ends) && ends[n] <= len(key) {
+				n++
+			}
+			return ends[:n]
+		}
+	case req.FldSeparator != "":
+		componentEnds = func(key string) []int {
+			ends := make([]int, 0, 5)
+			offset := 0
+			for {
+				i := strings.Index(key[offset:], req.FldSeparator)
+				if i == -1 {
+					return append(ends, len(key))
+				}
+				ends = append(ends, offset+i)
+				offset += i + len(req.FldSeparator)
+			}
+		}
+	}
+
+	var counts []*valueCounter // value counts for each component
+	var prevPrefixes []string  // merged prefix of previous key for each component
+	var distinct []int
+
+	// partition sizes, KeyCount / Partitions, the 1st KeyCount % Partitions partitions have 1 more key
+	partitionCnt := min(req.Partitions, stats.KeyCount)
+	var partitionSize, partitionRem, nextStart int
+	if partitionCnt > 0 {
+		partitionSize = stats.KeyCount / partitionCnt
+		partitionRem = stats.KeyCount % partitionCnt
+		stats.Partitions = make([]KeyRange, 0, partitionCnt)
+	}
+	var prevKey string
+	var keyNo int
+
+	csr := bkt.Cursor()
+	for k, _ := csr.First(); k != nil; k, _ = csr.Next() {
+		key := string(k)
+		if partitionSize > 0 {
+			if keyNo == nextStart && len(stats.Partitions) < partitionCnt {
+				if keyNo > 0 {
+					stats.Partitions[len(stats.Partitions)-1].EndKey = prevKey
+				}
+				stats.Partitions = append(stats.Partitions, KeyRange{StartKey: key})
+				stats.SplitKeys = append(stats.SplitKeys, key)
+				nextStart += partitionSize
+				if len(stats.Partitions) <= partitionRem {
+					nextStart++
+				}
+			}
+			stats.Partitions[len(stats.Partitions)-1].Count++
+		}
+		keyNo++
+		prevKey = key
+		if componentEnds == nil {
+			continue
+		}
+		start := 0
+		for i, end := range componentEnds(key) {
+			if i == len(counts) {
+				counts = append(counts, &valueCounter{counts: make(map[string]int)})
+				prevPrefixes = append(prevPrefixes, "")
+				distinct = append(distinct, 0)
+			}
+			counts[i].add(key[start:end])
+			if prefix := key[:end]; distinct[i] == 0 || prefix != prevPrefixes[i] { // keys are in order, so equal prefixes are adjacent
+				distinct[i]++
+				prevPrefixes[i] = prefix
+			}
+			start = end
+			if setting != nil {
+				start += len(setting.FldSeparator)
+			} else {
+				start += len(req.FldSeparator)
+			}
+		}
+	}
+	if len(stats.Partitions) > 0 {
+		stats.Partitions[len(stats.Partitions)-1].EndKey = prevKey
+	}
+
+	stats.Components = make([]ComponentStats, len(counts))
+	for i, valCounts := range counts {
+		name := strconv.Itoa(i)
+		if i < len(names) {
+			name = names[i]
+		}
+		topValues := make([]ValueCount, 0, len(valCounts.counts))
+		for val, count := range valCounts.counts {
+			if i < len(desc) && desc[i] {
+				val = descStr(val) // inverting again restores the value
+			}
+			topValues = append(topValues, ValueCount{Value: val, Count: count})
+		}
+		slices.SortFunc(topValues, func(a, b ValueCount) int {
+			if a.Count != b.Count {
+				return b.Count - a.Count
+			}
+			return strings.Compare(a.Value, b.Value)
+		})
+		if len(topValues) > req.TopValues {
+			topValues = topValues[:req.TopValues]
+		}
+		stats.Components[i] = ComponentStats{Name: name, DistinctPrefixes: distinct[i], TopValues: topValues, Approx: valCounts.approx}
+	}
+
+	var err error
+	resp.Rec, err = json.Marshal(&stats)
+	if err != nil {
+		log.Println("IndexStats json.Marshal failed-", err)
+		resp.Status = StatusFail
+		resp.Msg = "json.Marshal IndexStats failed-see server log"
+		return resp, nil
+	}
+	resp.GetCnt = stats.KeyCount
+	resp.Status = StatusOk
+	return resp, nil
+}
@@ -50,6 +50,7 @@ func openBkt(tx *bolt.Tx, resp *Response, bktName string, createIfNotExists ...b
 // See shortcut func GetBktList() in client/util.go.
 // Count operation returns number of keys in specified bkt.
 // See shortcut func GetRecCount() in client/util.go.
+// Note - "delete" does not remove index bkts or index settings, use BktAdminRequest (requests_admin.go) for that.
 type BktRequest struct {
 	BktName      string
 	Operation    string // "create", "delete", "nextseq", "list", "count"
@@ -84,6 +85,7 @@ func (req *BktRequest) Run(tx *bolt.Tx) (*Response, error) {
 		resp.NextSeq, err = bktNextSeq(bkt, req.NextSeqCount)
 	case BktList:
 		resp.Recs = make([][]byte, 0, 100)
+		resp.keyRecs = true
 		tx.ForEach(func(name []byte, b *bolt.Bucket) error {
 			resp.Recs = append(resp.Recs, name)
 			return nil
@@ -147,7 +149,7 @@ func (req *DeleteRequest) Run(tx *bolt.Tx) (*Response, error) {
 	if bkt == nil {
 		return resp, nil
 	}
-	indexBkts, indexInvertedBkts, err := getIndexBkts(tx, req.BktName)
+	indexrs, err := loadIndexrs(tx, req.BktName, false) // see requests_put.go, index bkts not created
 	if err != nil {
 		log.Println("error getting index bkts for data bkt", req.BktName, err)
 		resp.Status = StatusFail
@@ -163,12 +165,12 @@ func (req *DeleteRequest) Run(tx *bolt.Tx) (*Response, error) {
 			return resp, err // trans will be rolled back
 		}
 		// delete index entries for this data key
-		for i, indexBkt := range indexBkts {
-			indexInvertedBkt := indexInvertedBkts[i]
-			indexKey := indexInvertedBkt.Get([]byte(key))
-			if indexKey != nil {
-				indexBkt.Delete(indexKey)
-				indexInvertedBkt.Delete([]byte(key))
+		for _, indexr := range indexrs {
+			if err = indexr.Delete([]byte(key)); err != nil {
+				log.Println("db error - Delete index entry failed", err)
+				resp.Status = StatusFail
+				resp.Msg = "Delete index entry failed, see log for details"
+				return resp, err // trans will be rolled back
 			}
 		}
 	}
@@ -176,59 +178,6 @@ func (req *DeleteRequest) Run(tx *bolt.Tx) (*Response, error) {
 	return resp, nil
 }
 
-// getIndexBkts used by DeleteRequest.
-// Inverted bkt key is data key, val is index key. This allows us to find index entry for a data key.
-// getIndexBkts retrieves the index buckets and their corresponding inverted index buckets
-// for a given data bucket name within a BoltDB transaction.
-//
-// It looks up index settings in the IndexSettingsBkt bucket, filters them by the provided
-// dataBkt name, and collects the associated index and inverted index buckets.
-//
-// Parameters:
-//
-//	tx      - The BoltDB transaction to use for bucket access.
-//	dataBkt - The name of the data bucket for which to find index buckets.
-//
-// Returns:
-//
-//	indexBkts         - A slice of pointers to the found index buckets.
-//	indexInvertedBkts - A slice of pointers to the corresponding inverted index buckets.
-//	err               - An error if any occurs during processing (e.g., unmarshalling settings).
-func getIndexBkts(tx *bolt.Tx, dataBkt string) (indexBkts []*bolt.Bucket, indexInvertedBkts []*bolt.Bucket, err error) {
-
-	settingsBkt := tx.Bucket([]byte(IndexSettingsBkt))
-	if settingsBkt == nil {
-		return nil, nil, nil // no index settings, so no index bkts
-	}
-	csr := settingsBkt.Cursor()
-	prefix := []byte(dataBkt)
-	var setting IndexSetting
-	indexBkts = make([]*bolt.Bucket, 0, 5)
-	indexInvertedBkts = make([]*bolt.Bucket, 0, 5)
-	for k, v := csr.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = csr.Next() {
-		err = json.Unmarshal(v, &setting)
-		if err != nil {
-			return nil, nil, fmt.Errorf("error unmarshalling index setting for index bkt %s - %s", string(k), err.Error())
-		}
-		if setting.DataBkt != dataBkt {
-			continue // possible for prefix to match multiple data bkts, ex. "order" prefix matches "order", "order_item"
-		}
-		indexBkt := tx.Bucket([]byte(setting.IndexBkt))
-		if indexBkt == nil {
-			continue
-		}
-		indexBkts = append(indexBkts, indexBkt)
-
-		indexInvertedBktName := setting.IndexBkt + "_inverted"
-		indexInvertedBkt := tx.Bucket([]byte(indexInvertedBktName))
-		if indexInvertedBkt == nil {
-			continue
-		}
-		indexInvertedBkts = append(indexInvertedBkts, indexInvertedBkt)
-	}
-	return indexBkts, indexInvertedBkts, nil
-}
-
 // Export writes bkt records to a file as formatted json.
 type ExportRequest struct {
 	BktName  string
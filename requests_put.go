@@ -90,7 +90,7 @@ func (req *PutRequest) Run(tx *bolt.Tx) (*Response, error) {
 			}
 		}
 		if parms.IndexingOption != IndexingOff {
-			indexrs, err = loadIndexrs(tx, parms.BktName)
+			indexrs, err = loadIndexrs(tx, parms.BktName, true)
 			if err != nil {
 				resp.Status = StatusFail
 				resp.Msg = "PutRequest failed, error in loadIndexrs-" + err.Error()
@@ -222,7 +222,8 @@ func validatePutParms(parms *PutParm) error {
 
 // loadIndexrs loads indexrs for a data bkt using index settings from index_settings bkt
 // The Indexr type which performs the indexing operations, is defined in indexr.go.
-func loadIndexrs(tx *bolt.Tx, dataBkt string) (indexrs []Indexr, err error) {
+// If createBkts is false, indexes with missing index bkts are skipped (DeleteRequest), else the bkts are created.
+func loadIndexrs(tx *bolt.Tx, dataBkt string, createBkts bool) (indexrs []Indexr, err error) {
 
 	settingsBkt := tx.Bucket([]byte(IndexSettingsBkt))
 	if settingsBkt == nil {
@@ -242,6 +243,12 @@ func loadIndexrs(tx *bolt.Tx, dataBkt string) (indexrs []Indexr, err error) {
 		if setting.DataBkt != dataBkt {
 			continue // possible for prefix to match multiple data bkts, ex. "order" prefix matches "order", "order_item"
 		}
+		if !createBkts {
+			if indexr = openIndexr(tx, &setting); indexr != nil {
+				indexrs = append(indexrs, *indexr)
+			}
+			continue
+		}
 		indexr, err = NewIndxr(tx, &setting)
 		if err != nil {
 			return nil, err
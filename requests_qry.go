@@ -7,10 +7,17 @@ See QryRequest type for details.
 */
 
 import (
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"regexp"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/valyala/fastjson"
 	bolt "go.etcd.io/bbolt"
@@ -19,15 +26,25 @@ import (
 // SortKey is used by QryRequest to sort results.
 // Only fields of type string or int are currently supported.
 // String values are converted to "plain" string (lowercase, alphanumeric).
+// If Expr is set, the expression result is sorted instead of Fld (see expr.go).
 type SortKey struct {
 	Fld        string // name of field
 	Dir        string // direction (asc/desc) and field type (str/int)
 	UseDefault string // controls what value is used when fld NotFound or IsNull, see codes.go
+	Expr       string // optional, expression used instead of Fld, ex. "qty * price"
+
+	expr *expr // parsed from Expr by validateSortKeys
 }
 
 // FindCondition is used by QryRequest to define select criteria.
 // Each record's Fld value is compared to FindCondition value.
 // See codes.go for Find* op code constants.
+// FindRegex - ValStr is a RE2 regular expression, matched against rec value after StrOption conversion.
+// Unless StrOption is StrAsIs, matching is case insensitive.
+// FindFuzzy - rec value matches if edit distance (Levenshtein) from ValStr is <= MaxDist.
+// Expr replaces Fld and ValExpr replaces ValStr/ValInt with an expression evaluated for each record (see expr.go).
+// Ex. {Fld: "shipDate", Op: FindAfter, ValExpr: "orderDate"} or {Expr: "qty * price", Op: FindGreaterThan, ValInt: 1000}.
+// Int ops compare expression results as float, so fractional results are not truncated.
 type FindCondition struct {
 	Fld        string   // field containing compare value
 	Op         string   // defines match operation and value type
@@ -38,44 +55,93 @@ type FindCondition struct {
 	Not        bool     // exclude records that meet condition
 	UseDefault string   // controls what default value is used, see Default* codes in codes.go
 	StrOption  string   // controls string conversion, see Str* codes in codes.go, default StrLowerCase
+	MaxDist    int      // used by op FindFuzzy, max edit distance, default 1
+	Expr       string   // optional, expression used instead of Fld
+	ValExpr    string   // optional, expression used instead of ValStr/ValInt, not valid for list, regex, exists, isnull ops
+	SubQry     *SubQry  // optional, list ops and exists use results of a query on another bkt, see subqry.go
+
+	regex     *regexp.Regexp // used by op FindRegex, compiled from ValStr by validateFindConditions
+	expr      *expr          // parsed from Expr by validateFindConditions
+	valExpr   *expr          // parsed from ValExpr by validateFindConditions
+	strSet    map[string]bool
+	intSet    map[int]bool // strSet, intSet and subExists are loaded by resolveSubQrys
+	subExists bool
 }
 
 type FindGroup []FindCondition // QryRequest can have multiple FindGroups that are ORed together
 
-type Join struct {
-	JoinBkt    string // name of related bkt where value is pulled from
-	JoinFld    string // fld in primary rec containing key value of join rec
-	FromFld    string // fld in join rec where value comes from
-	ToFld      string // fld in primary rec where value is loaded
-	UseDefault bool   // if join problem, use default value, no error
-}
-
 // QryRequest is used to filter and sort recs from a bkt.
 // Start/End keys define range of keys to read.
 // If StartKey == EndKey, key prefix must match StartKey.
 type QryRequest struct {
-	BktName         string      // primary data bkt
-	IndexBkt        string      // optional index bkt name, start/end keys use index
-	Criteria        []FindGroup // if a record meets all conditions in any FindGroup, it is included in results
-	SortKeys        []SortKey   // defines sort order, if omitted ressults returned in key order
-	StartKey        string      // begin range, 1st key >=
-	EndKey          string      // end range, last key <=
-	Limit           int         // limits results before sort step
-	Top             int         // limits results after sort step
-	ErrLimit        int         // run stops when ErrLimit exceeded, default 0, settings.MaxErrs limit if -1
-	JoinsBeforeFind []Join      // joined values can be used in find step (adds processing time)
-	JoinsAfterFind  []Join      // joined values can be used for sort step but not find step
-	CountOnly       bool        // if true, Response.Recs is nil, count in Response.GetCnt
+	BktName              string        // primary data bkt
+	IndexBkt             string        // optional index bkt name, start/end keys use index
+	Criteria             []FindGroup   // if a record meets all conditions in any FindGroup, it is included in results
+	SortKeys             []SortKey     // defines sort order, if omitted ressults returned in key order
+	StartKey             string        // begin range, 1st key >=
+	EndKey               string        // end range, last key <=
+	Limit                int           // limits results before sort step
+	Top                  int           // limits results after sort step
+	ErrLimit             int           // run stops when ErrLimit exceeded, default 0, settings.MaxErrs limit if -1
+	JoinsBeforeFind      []Join        // joined values can be used in find step (adds processing time)
+	JoinsAfterFind       []Join        // joined values can be used for sort step but not find step
+	ChildJoinsBeforeFind []ChildJoin   // child recs and counts can be used in find step, see childjoin.go
+	ChildJoinsAfterFind  []ChildJoin   // child recs and counts can be used for sort step but not find step
+	CountOnly            bool          // if true, Response.Recs is nil, count in Response.GetCnt
+	Reverse              bool          // if true, read keys in descending order, use resp.NextKey as EndKey for next page
+	Computed             []ComputedFld // flds added to result recs after JoinsAfterFind, can be used by SortKeys
+	Fields               []string      // optional, return only these flds, see projection.go
+	Parallel             int           // optional, max # of workers scanning partitions of the range, see parallel.go
+	Explain              bool          // if true, Response.Stats contains execution statistics and plan, see stats.go
+	WithMeta             bool          // if true, Response.Meta contains the key, index key and size of each rec, see RecMeta in types.go
+	Snapshot             string        // optional, Id of open snapshot the request reads, see snapshot.go
+
+	facets   *facetCounter // set by FacetRequest, counts flds of matching recs
+	prepared *preparedQry  // set by RunSavedQueryRequest, validated Criteria and SortKeys
+}
+
+// preparedQry contains the validated Criteria and SortKeys of a QryRequest.
+// It is not changed after prepareQry, so it can be shared by concurrent requests.
+type preparedQry struct {
+	criteria []FindGroup
+	sortKeys []SortKey
+}
+
+// prepareQry validates Criteria and SortKeys, loads defaults, and compiles regex and expressions.
+func prepareQry(req *QryRequest) (*preparedQry, error) {
+	prepared := new(preparedQry)
+	var err error
+	if len(req.Criteria) > 0 {
+		prepared.criteria = make([]FindGroup, len(req.Criteria))
+		for i, group := range req.Criteria {
+			prepared.criteria[i], err = validateFindConditions(group)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Criteria group %d - %s", i, err.Error())
+			}
+		}
+	}
+	// validate and set defaults
+	prepared.sortKeys, err = validateSortKeys(req.SortKeys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SortKeys- %s", err.Error())
+	}
+	return prepared, nil
 }
 
 func (req QryRequest) IsUpdtReq() bool {
 	return false
 }
 
+func (req QryRequest) SnapshotId() string {
+	return req.Snapshot
+}
+
 // SortRec is used when QryRequest has SortKeys
 type SortRec struct {
-	SortOn []string // Values extracted from record using SortKeys
+	SortOn []byte   // sort key, values extracted from record using SortKeys, see encodeSortKey
 	Value  []byte   // record value
+	seq    int      // read order, breaks ties so results are deterministic
+	meta   *RecMeta // set if QryRequest.WithMeta
 }
 
 func (req *QryRequest) Run(tx *bolt.Tx) (*Response, error) {
@@ -88,114 +154,381 @@ func (req *QryRequest) Run(tx *bolt.Tx) (*Response, error) {
 	}
 	var index *bolt.Bucket
 	if req.IndexBkt != "" {
+		if err := notTextIndex(tx, req.IndexBkt); err != nil {
+			resp.Status = StatusFail
+			resp.Msg = err.Error()
+			return resp, nil
+		}
 		index = openBkt(tx, resp, req.IndexBkt)
 		if index == nil {
 			return resp, nil
 		}
 	}
+	var stats *ExecStats // nil unless Explain
+	if req.Explain {
+		stats = new(ExecStats)
+	}
+	t := stats.now()
+	scan := newQryScan(tx, req, resp)
+	if scan == nil {
+		return resp, nil
+	}
+	stats.since("validate", t)
+	Trace("__ Qry find start __")
+
+	results, partitionCnt := scan.exec(bkt, index, 0, stats)
+	Trace("__ Qry find done __")
+	if stats != nil {
+		stats.Plan = scan.plan(partitionCnt)
+	}
+	scan.finish(resp, results, stats)
+	return resp, nil
+}
+
+// newQryScan validates req and returns the qryScan used to run it.
+// If req is invalid, resp Status and Msg are set and nil is returned.
+func newQryScan(tx *bolt.Tx, req *QryRequest, resp *Response) *qryScan {
 	var err error
+	scan := &qryScan{req: req, tx: tx}
 
-	var validatedCriteria []FindGroup
-	if len(req.Criteria) > 0 {
-		validatedCriteria = make([]FindGroup, len(req.Criteria))
-		for i, group := range req.Criteria {
-			validatedCriteria[i], err = validateFindConditions(group)
-			if err != nil {
-				resp.Status = StatusFail
-				resp.Msg = fmt.Sprintf("invalid Criteria group %d - %s", i, err.Error())
-				return resp, nil
-			}
+	prepared := req.prepared // set if validated criteria and sort keys are cached, see RunSavedQueryRequest
+	if prepared == nil {
+		if prepared, err = prepareQry(req); err != nil {
+			resp.Status = StatusFail
+			resp.Msg = err.Error()
+			return nil
 		}
 	}
+	scan.criteria, scan.sortKeys = prepared.criteria, prepared.sortKeys
 
-	var validatedSortKeys []SortKey
-	// validate and set defaults
-	validatedSortKeys, err = validateSortKeys(req.SortKeys)
+	scan.criteria, err = resolveSubQrys(tx, scan.criteria, &scan.subErrs) // see subqry.go
 	if err != nil {
 		resp.Status = StatusFail
-		resp.Msg = "invalid SortKeys- " + err.Error()
-		return resp, nil
+		resp.Msg = "invalid Criteria- " + err.Error()
+		return nil
+	}
+
+	scan.computed, err = compileComputedFlds(req.Computed) // see expr.go
+	if err != nil {
+		resp.Status = StatusFail
+		resp.Msg = "invalid Computed- " + err.Error()
+		return nil
+	}
+
+	scan.proj, err = newProjection(req.Fields) // see projection.go
+	if err != nil {
+		resp.Status = StatusFail
+		resp.Msg = "invalid Fields- " + err.Error()
+		return nil
+	}
+
+	scan.joinsBefore, err = compileJoins(tx, req.JoinsBeforeFind) // see join.go
+	if err == nil {
+		scan.joinsAfter, err = compileJoins(tx, req.JoinsAfterFind)
+	}
+	if err != nil {
+		resp.Status = StatusFail
+		resp.Msg = "invalid Joins- " + err.Error()
+		if errors.Is(err, errJoinBkt) {
+			resp.Errs = append(resp.Errs, *e(ErrJoinBkt, err.Error(), nil, nil))
+		}
+		return nil
 	}
 
-	var sortRecs []SortRec
-	if len(validatedSortKeys) > 0 {
-		sortRecs = make([]SortRec, 0, InitialRespRecsSize)
+	scan.childBefore, err = compileChildJoins(tx, req.ChildJoinsBeforeFind, &scan.subErrs) // see childjoin.go
+	if err == nil {
+		scan.childAfter, err = compileChildJoins(tx, req.ChildJoinsAfterFind, &scan.subErrs)
+	}
+	if err != nil {
+		resp.Status = StatusFail
+		resp.Msg = "invalid ChildJoins- " + err.Error()
+		return nil
+	}
+
+	if req.ErrLimit == -1 { // see server/bobb_settings.json for MaxErrs value (defined in util.go)
+		req.ErrLimit = MaxErrs
+	}
+	return scan
+}
+
+// exec scans the request range of bkt (or index), in parallel partitions if possible.
+// Results are in key order, 1 per partition. Param seqBase is added to the read order of recs, see run.
+// Returns the results and the # of partitions (0 if sequential). Phase times are added to stats if not nil.
+func (scan *qryScan) exec(bkt, index *bolt.Bucket, seqBase int, stats *ExecStats) ([]*qryScanResult, int) {
+	req := scan.req
+	var results []*qryScanResult
+	t := stats.now()
+	partitions := qryPartitions(req, bkt)
+	if len(partitions) > 1 {
+		stats.since("partition", t)
+		t = stats.now()
+		results = scan.runParallel(bkt, partitions, seqBase) // see parallel.go
+	} else {
+		readLoop := NewReadLoop(bkt, index)
+		readLoop.Reverse = req.Reverse
+		results = []*qryScanResult{scan.run(readLoop, req.StartKey, req.EndKey, seqBase)}
+	}
+	stats.since("scan", t)
+	return results, len(partitions)
+}
+
+// finish loads resp from the scan results: errors, NextKey, recs in key or sort order, GetCnt and Status.
+func (scan *qryScan) finish(resp *Response, results []*qryScanResult, stats *ExecStats) {
+	req := scan.req
+	if stats != nil {
+		for _, result := range results {
+			stats.add(&result.stats)
+		}
+		resp.Stats = stats
+	}
+
+	failed := false
+	for _, result := range results {
+		resp.Errs = append(resp.Errs, result.errs...)
+		if result.failed || len(resp.Errs) > req.ErrLimit {
+			failed = true
+			break // later partitions are not in results of a sequential run
+		}
+	}
+	if failed {
+		resp.Errs = resp.Errs[:min(len(resp.Errs), req.ErrLimit+1)]
+		resp.Status = StatusFail
+		resp.Msg = "too many errors, see resp.Errs for details"
+		return
+	}
+	if nextKey := results[len(results)-1].nextKey; nextKey != nil { // ReadLoop.NextKey is loaded by readLoop.Next() at end of range.
+		resp.NextKey = string(nextKey)
+	}
+
+	if len(scan.sortKeys) > 0 {
+		t := stats.now()
+		resp.Recs, resp.Meta = mergeSortRecs(results, req.Top)
+		stats.since("merge", t)
+	} else if len(results) == 1 {
+		resp.Recs, resp.Meta = results[0].recs, results[0].meta
 	} else {
 		resp.Recs = make([][]byte, 0, InitialRespRecsSize)
+		for _, result := range results {
+			resp.Recs = append(resp.Recs, result.recs...)
+			resp.Meta = append(resp.Meta, result.meta...)
+		}
+	}
+	resp.GetCnt = len(resp.Recs)
+	if req.CountOnly {
+		resp.Recs, resp.Meta = nil, nil // clear recs
+	}
+	resp.Errs = append(resp.Errs, scan.subErrs...) // not counted for ErrLimit
+	if len(resp.Errs) > 0 {
+		resp.Status = StatusWarning
+		resp.Msg = "see resp.Errs for details"
+	} else {
+		resp.Status = StatusOk
+	}
+}
+
+// qryScan holds the validated parts of a QryRequest, used to scan a key range.
+// Parsers, arena and projection are created by each run, so runs on different partitions can be concurrent.
+type qryScan struct {
+	req      *QryRequest
+	tx       *bolt.Tx
+	criteria []FindGroup
+	sortKeys []SortKey
+	computed []compiledFld
+	proj     *projection
+
+	joinsBefore []compiledJoin
+	joinsAfter  []compiledJoin
+	childBefore []compiledChildJoin
+	childAfter  []compiledChildJoin
+
+	srcFld string          // if set, srcVal is loaded into this fld of each rec, see UnionQryRequest
+	srcVal *fastjson.Value // source bkt name
+
+	subErrs []BobbErr // Errs of subqueries with StatusWarning, see resolveSubQrys
+}
+
+// qryScanResult contains the results of scanning 1 key range.
+type qryScanResult struct {
+	recs     [][]byte  // matching recs in key order, if no SortKeys
+	meta     []RecMeta // meta of recs, if WithMeta
+	sortRecs []SortRec // matching recs in sort order, if SortKeys (only best Top recs if Top set)
+	errs     []BobbErr
+	failed   bool      // ErrLimit exceeded, scan stopped
+	nextKey  []byte    // 1st key after range or limit
+	stats    ExecStats // counts always kept, phase times only if Explain
+}
+
+// plan describes how the request is run, used for ExecStats.Plan.
+func (scan *qryScan) plan(partitionCnt int) string {
+	req := scan.req
+	plan := []string{rangePlan(req.BktName, req.IndexBkt, req.StartKey, req.EndKey, req.Reverse)}
+	if partitionCnt > 1 {
+		plan = append(plan, fmt.Sprintf("parallel %d partitions, %d workers", partitionCnt, min(req.Parallel, maxQryWorkers, partitionCnt)))
+	}
+	if req.Limit > 0 {
+		plan = append(plan, fmt.Sprintf("limit %d", req.Limit))
+	}
+	if len(req.JoinsBeforeFind) > 0 {
+		plan = append(plan, fmt.Sprintf("%d joins before find", len(req.JoinsBeforeFind)))
+	}
+	if len(scan.childBefore) > 0 {
+		plan = append(plan, fmt.Sprintf("%d child joins before find", len(scan.childBefore)))
+	}
+	if len(scan.criteria) > 0 {
+		plan = append(plan, fmt.Sprintf("find %d groups", len(scan.criteria)))
+	}
+	if len(req.JoinsAfterFind) > 0 {
+		plan = append(plan, fmt.Sprintf("%d joins after find", len(req.JoinsAfterFind)))
+	}
+	if len(scan.childAfter) > 0 {
+		plan = append(plan, fmt.Sprintf("%d child joins after find", len(scan.childAfter)))
+	}
+	if len(scan.computed) > 0 {
+		plan = append(plan, fmt.Sprintf("%d computed flds", len(scan.computed)))
+	}
+	switch {
+	case len(scan.sortKeys) > 0 && req.Top > 0:
+		plan = append(plan, fmt.Sprintf("sort %d keys, top %d heap", len(scan.sortKeys), req.Top))
+	case len(scan.sortKeys) > 0:
+		plan = append(plan, fmt.Sprintf("sort %d keys, full sort", len(scan.sortKeys)))
+	default:
+		plan = append(plan, "key order")
+	}
+	if scan.proj != nil {
+		plan = append(plan, fmt.Sprintf("project %d flds", len(scan.proj.flds)))
+	}
+	return strings.Join(plan, "; ")
+}
+
+// run reads the range of readLoop and applies the find, join, computed, sort key and projection steps.
+// Param seqBase is added to the read order of each rec, so ties between partitions sort in key order.
+func (scan *qryScan) run(readLoop *ReadLoop, startKey, endKey string, seqBase int) *qryScanResult {
+	req := scan.req
+	result := new(qryScanResult)
+	var stats *ExecStats // phase times, nil unless Explain
+	if req.Explain {
+		stats = &result.stats
+	}
+	var t time.Time
+
+	var topRecs *topHeap // used instead of sortRecs when Top is set, holds best Top recs
+	if len(scan.sortKeys) > 0 && req.Top > 0 {
+		topRecs = newTopHeap(req.Top)
+	} else if len(scan.sortKeys) > 0 {
+		result.sortRecs = make([]SortRec, 0, InitialRespRecsSize)
+	} else {
+		result.recs = make([][]byte, 0, InitialRespRecsSize)
 	}
 	parser := parserPool.Get() // defined in util.go
 	defer parserPool.Put(parser)
 
-	var joinParser *fastjson.Parser // used by loadJoinValues func
-	if len(req.JoinsBeforeFind) > 0 || len(req.JoinsAfterFind) > 0 {
-		joinParser = parserPool.Get()
-		defer parserPool.Put(joinParser)
+	var joinsBefore, joinsAfter *joinLoader // load Joins
+	if len(scan.joinsBefore) > 0 {
+		joinsBefore = newJoinLoader(scan.joinsBefore)
+		defer joinsBefore.release()
 	}
-
-	if req.ErrLimit == -1 { // see server/bobb_settings.json for MaxErrs value (defined in util.go)
-		req.ErrLimit = MaxErrs
+	if len(scan.joinsAfter) > 0 {
+		joinsAfter = newJoinLoader(scan.joinsAfter)
+		defer joinsAfter.release()
+	}
+	var childBefore, childAfter *childLoader // load ChildJoins
+	if len(scan.childBefore) > 0 {
+		childBefore = newChildLoader(scan.tx, scan.childBefore)
+		defer childBefore.release()
+	}
+	if len(scan.childAfter) > 0 {
+		childAfter = newChildLoader(scan.tx, scan.childAfter)
+		defer childAfter.release()
+	}
+	var arena *fastjson.Arena // used by loadComputedValues
+	if len(scan.computed) > 0 {
+		arena = new(fastjson.Arena)
+	}
+	var proj *projection
+	if scan.proj != nil {
+		proj = &projection{flds: scan.proj.flds} // own arena
 	}
 
+	var err error
 	var parsedRec *fastjson.Value
 	var bErr *BobbErr
-	var keep bool // used to indicate if rec meets either FindConditions
-	var sortVals []string
-
-	Trace("__ Qry find start __")
+	var keep bool                // used to indicate if rec meets either FindConditions
+	var sortOn, sortOnBuf []byte // sortOnBuf is reused, sortOn copied only when rec is kept
 
 	var k, v []byte // key, value returned by readLoop
 
-	readLoop := NewReadLoop(bkt, index)
-	k, v, bErr = readLoop.Start(req.StartKey, req.EndKey, req.Limit)
+	k, v, bErr = readLoop.Start(startKey, endKey, req.Limit)
 	if bErr != nil {
-		resp.Errs = append(resp.Errs, *bErr)
+		result.errs = append(result.errs, *bErr)
 		k, v, bErr = readLoop.Next()
 	}
 
 	for k != nil {
-		if len(resp.Errs) > req.ErrLimit {
-			resp.Status = StatusFail
-			resp.Msg = "too many errors, see resp.Errs for details"
-			return resp, nil
+		if len(result.errs) > req.ErrLimit {
+			result.failed = true
+			break
 		}
+		result.stats.KeysScanned++
 		if bErr != nil { // triggered when readLoop returns errCode
-			resp.Errs = append(resp.Errs, *bErr)
+			result.errs = append(result.errs, *bErr)
 			k, v, bErr = readLoop.Next()
 			continue
 		}
 		// parse data record
+		t = stats.now()
 		parsedRec, err = parser.ParseBytes(v)
+		stats.since("parse", t)
 		if err != nil {
 			bErr = e(ErrParseRec, err.Error(), k, v)
-			resp.Errs = append(resp.Errs, *bErr)
+			result.errs = append(result.errs, *bErr)
 			k, v, bErr = readLoop.Next()
 			continue
 		}
+		result.stats.RecsParsed++
+		size := len(v) // stored size, for RecMeta
+		if scan.srcFld != "" {
+			parsedRec.Set(scan.srcFld, scan.srcVal) // before find, so criteria and sort keys can use it
+		}
 
 		// add joined values before find step
-		if len(req.JoinsBeforeFind) > 0 {
-			v, bErr = loadJoinValues(tx, parsedRec, req.JoinsBeforeFind, joinParser)
+		if joinsBefore != nil {
+			t = stats.now()
+			v, bErr = joinsBefore.load(parsedRec, &result.stats.JoinLookups)
+			stats.since("join", t)
+			if bErr != nil {
+				bErr.Key, bErr.Val = k, v
+				result.errs = append(result.errs, *bErr)
+				k, v, bErr = readLoop.Next()
+				continue
+			}
+		}
+		if childBefore != nil {
+			t = stats.now()
+			v, bErr = childBefore.load(parsedRec, &result.stats.JoinLookups)
+			stats.since("join", t)
 			if bErr != nil {
 				bErr.Key, bErr.Val = k, v
-				resp.Errs = append(resp.Errs, *bErr)
+				result.errs = append(result.errs, *bErr)
 				k, v, bErr = readLoop.Next()
 				continue
 			}
 		}
 
-		if len(validatedCriteria) == 0 {
+		t = stats.now()
+		if len(scan.criteria) == 0 {
 			keep = true // no criteria, all recs meet criteria
 		} else {
-			for _, findGroup := range validatedCriteria {
+			for _, findGroup := range scan.criteria {
 				keep, bErr = parsedRecFind(parsedRec, findGroup)
 				if bErr != nil || keep { // if error or rec meets criteria, no need to check other findGroups
 					break
 				}
 			}
 		}
+		stats.since("find", t)
 		if bErr != nil {
 			bErr.Key, bErr.Val = k, v
-			resp.Errs = append(resp.Errs, *bErr)
+			result.errs = append(result.errs, *bErr)
 			k, v, bErr = readLoop.Next()
 			continue
 		}
@@ -204,185 +537,216 @@ func (req *QryRequest) Run(tx *bolt.Tx) (*Response, error) {
 			continue
 		}
 		readLoop.Count++
+		result.stats.RecsMatched++
+		if req.facets != nil {
+			req.facets.add(parsedRec)
+		}
 
 		// add joined values after find step
-		if len(req.JoinsAfterFind) > 0 {
-			v, bErr = loadJoinValues(tx, parsedRec, req.JoinsAfterFind, joinParser)
+		if joinsAfter != nil {
+			t = stats.now()
+			v, bErr = joinsAfter.load(parsedRec, &result.stats.JoinLookups)
+			stats.since("join", t)
+			if bErr != nil {
+				bErr.Key, bErr.Val = k, v
+				result.errs = append(result.errs, *bErr)
+				k, v, bErr = readLoop.Next()
+				continue
+			}
+		}
+		if childAfter != nil {
+			t = stats.now()
+			v, bErr = childAfter.load(parsedRec, &result.stats.JoinLookups)
+			stats.since("join", t)
+			if bErr != nil {
+				bErr.Key, bErr.Val = k, v
+				result.errs = append(result.errs, *bErr)
+				k, v, bErr = readLoop.Next()
+				continue
+			}
+		}
+
+		// add computed flds, after joins so expressions can use joined values
+		if len(scan.computed) > 0 {
+			t = stats.now()
+			v, bErr = loadComputedValues(parsedRec, scan.computed, arena)
+			stats.since("computed", t)
 			if bErr != nil {
 				bErr.Key, bErr.Val = k, v
-				resp.Errs = append(resp.Errs, *bErr)
+				result.errs = append(result.errs, *bErr)
 				k, v, bErr = readLoop.Next()
 				continue
 			}
 		}
 
-		// if Sorting, extract values used for sorting, else add value to resp.Recs
-		if len(validatedSortKeys) > 0 {
-			sortVals, bErr = extractSortVals(parsedRec, validatedSortKeys)
+		// if Sorting, extract values used for sorting, else add value to result.recs
+		seq := seqBase + readLoop.Count
+		if len(scan.sortKeys) > 0 {
+			t = stats.now()
+			sortOnBuf, bErr = encodeSortKey(sortOnBuf[:0], parsedRec, scan.sortKeys)
+			stats.since("sortkey", t)
 			if bErr != nil {
 				bErr.Key, bErr.Val = k, v
-				resp.Errs = append(resp.Errs, *bErr)
+				result.errs = append(result.errs, *bErr)
 				k, v, bErr = readLoop.Next()
 				continue
 			}
-			sortRecs = append(sortRecs, SortRec{SortOn: sortVals, Value: v})
+			if topRecs != nil && !topRecs.accepts(sortOnBuf, seq) {
+				k, v, bErr = readLoop.Next() // not in top recs, skip projection
+				continue
+			}
+			sortOn = slices.Clone(sortOnBuf)
+		}
+		if proj != nil { // sort key already extracted, so projection can drop sort flds
+			t = stats.now()
+			v = proj.apply(parsedRec)
+			stats.since("project", t)
+		} else if scan.srcFld != "" {
+			v = parsedRec.MarshalTo(nil) // include srcFld, v may be the unchanged bkt value
+		}
+		var meta *RecMeta
+		if req.WithMeta {
+			recMeta := readLoop.recMeta(k, size)
+			meta = &recMeta
+		}
+		if topRecs != nil {
+			topRecs.add(SortRec{SortOn: sortOn, Value: v, seq: seq, meta: meta})
+		} else if len(scan.sortKeys) > 0 {
+			result.sortRecs = append(result.sortRecs, SortRec{SortOn: sortOn, Value: v, seq: seq, meta: meta})
 		} else {
-			resp.Recs = append(resp.Recs, v)
+			result.recs = append(result.recs, v)
+			if meta != nil {
+				result.meta = append(result.meta, *meta)
+			}
 		}
 
 		k, v, bErr = readLoop.Next()
 	}
-
-	if readLoop.NextKey != nil { // ReadLoop.NextKey is loaded by readLoop.Next() at end of range.
-		resp.NextKey = string(readLoop.NextKey)
+	if len(result.errs) > req.ErrLimit {
+		result.failed = true
 	}
-	Trace("__ Qry find done __")
-
-	if len(validatedSortKeys) > 0 {
-		qrySort(validatedSortKeys, sortRecs)
-		count := len(sortRecs)
-		if req.Top > 0 && req.Top < count {
-			count = req.Top
-		}
-		resp.Recs = make([][]byte, count)
-		for i := range count {
-			resp.Recs[i] = sortRecs[i].Value
-		}
+	result.stats.ErrsSkipped = len(result.errs)
+	if readLoop.UsingIndex {
+		result.stats.IndexLookups = result.stats.KeysScanned
 	}
-	resp.GetCnt = len(resp.Recs)
-	if req.CountOnly {
-		resp.Recs = nil // clear recs
+	if result.failed {
+		return result
 	}
-	if len(resp.Errs) > 0 {
-		resp.Status = StatusWarning
-		resp.Msg = "see resp.Errs for details"
-	} else {
-		resp.Status = StatusOk
+	result.nextKey = readLoop.NextKey
+
+	t = stats.now()
+	if topRecs != nil {
+		result.sortRecs = topRecs.sorted()
+	} else if len(scan.sortKeys) > 0 {
+		qrySort(result.sortRecs)
 	}
-	return resp, nil
+	stats.since("sort", t)
+	return result
 }
 
-func qrySort(sortKeys []SortKey, sortRecs []SortRec) {
+func qrySort(sortRecs []SortRec) {
 	Trace("~ qry sort start ~")
+	slices.SortFunc(sortRecs, compareSortRecs) // slices pkg added in Go 1.21
+	Trace("~ qry sort done ~")
+}
 
-	sortDir := make([]int, len(sortKeys))
-	for i, sortKey := range sortKeys {
-		if slices.Contains(DescSortCodes, sortKey.Dir) {
-			sortDir[i] = -1
-		} else {
-			sortDir[i] = 1
-		}
+// compareSortRecs compares sort keys, direction is already encoded in the keys, ties in read order.
+func compareSortRecs(a, b SortRec) int {
+	if n := bytes.Compare(a.SortOn, b.SortOn); n != 0 {
+		return n
 	}
-	slices.SortFunc(sortRecs, func(a, b SortRec) (n int) { // slices pkg added in Go 1.21
-		for i := range sortKeys {
-			n = strings.Compare(a.SortOn[i], b.SortOn[i])
-			if n == 0 {
-				continue // sort vals match
-			}
-			n = sortDir[i] * n
-			break
-		}
-		return
-	})
-	Trace("~ qry sort done ~")
+	return a.seq - b.seq
 }
 
-// loadJoinValues adds values from a different bucket to parsed primary data record.
-// The joins parm specifies join information. See Join type for details.
-// If UseDefault true, on error, no join value(s) added to rec and no error returned.
-// Client side UnMarshal will load default (zero value) into struct join flds.
-func loadJoinValues(tx *bolt.Tx, parsedRec *fastjson.Value, joins []Join, joinParser *fastjson.Parser) (recBytes []byte, bErr *BobbErr) {
-	var err error
-	var prevJoinBkt string // name of prevJoinBkt
-	var prevJoinFld string
-	var joinBkt *bolt.Bucket
-	var joinRec []byte          // record from join bkt
-	var joinKey []byte          // key used to get join record
-	var joinVal *fastjson.Value // value from join bkt
-	var parsedJoinRec *fastjson.Value
-
-	for _, join := range joins {
-
-		// Note - if the JoinBkt and JoinFld are same as prev join, then the parsedJoinRec is reused
-		if join.JoinBkt != prevJoinBkt {
-			joinBkt = tx.Bucket([]byte(join.JoinBkt))
-			if joinBkt == nil {
-				emsg := fmt.Sprintf("invalid join bkt, %s", join.JoinBkt)
-				bErr = e(ErrJoinBkt, emsg, nil, nil)
-				return
-			}
-			prevJoinBkt = join.JoinBkt
-			prevJoinFld = ""
-		}
-		if join.JoinFld != prevJoinFld {
-			joinKey = parsedRec.GetStringBytes(join.JoinFld) // get key of record in join bkt
-			if joinKey == nil {
-				if join.UseDefault {
-					continue
-				}
-				emsg := fmt.Sprintf("invalid join fld, %s", join.JoinFld)
-				bErr = e(ErrJoinFld, emsg, nil, nil)
-				return
-			}
-			joinRec = joinBkt.Get(joinKey) // get record from join bkt
-			if joinRec == nil {
-				if join.UseDefault {
-					continue
-				}
-				emsg := fmt.Sprintf("join key %s not in join bkt %s", string(joinKey), join.JoinBkt)
-				bErr = e(ErrJoinKey, emsg, nil, nil)
-				return
-			}
-			parsedJoinRec, err = joinParser.ParseBytes(joinRec) // parse join record
-			if err != nil {
-				if join.UseDefault {
-					continue
-				}
-				emsg := fmt.Sprintf("error parsing join rec, key %s, val %s", joinKey, string(joinRec))
-				bErr = e(ErrJoinParse, emsg, nil, nil)
-				return
-			}
-			prevJoinFld = join.JoinFld
-		}
-		joinVal = parsedJoinRec.Get(join.FromFld)
-		if joinVal == nil {
-			if join.UseDefault {
-				continue
-			}
-			emsg := fmt.Sprintf("join from fld not found, key %s, FromFld %s", joinKey, join.FromFld)
-			bErr = e(ErrJoinFromFld, emsg, nil, nil)
-			return
-		}
-		parsedRec.Set(join.ToFld, joinVal)
+// topHeap keeps the best Top recs seen so far, so memory and sort time depend on Top, not # of matches.
+// It is a max heap, the worst kept rec is at index 0 and is replaced when a better rec is added.
+type topHeap struct {
+	recs []SortRec
+	top  int
+}
+
+func newTopHeap(top int) *topHeap {
+	return &topHeap{recs: make([]SortRec, 0, min(top, InitialRespRecsSize)), top: top}
+}
+
+func (h *topHeap) Len() int           { return len(h.recs) }
+func (h *topHeap) Less(i, j int) bool { return compareSortRecs(h.recs[i], h.recs[j]) > 0 }
+func (h *topHeap) Swap(i, j int)      { h.recs[i], h.recs[j] = h.recs[j], h.recs[i] }
+func (h *topHeap) Push(x any)         { h.recs = append(h.recs, x.(SortRec)) }
+func (h *topHeap) Pop() any {
+	last := h.recs[len(h.recs)-1]
+	h.recs = h.recs[:len(h.recs)-1]
+	return last
+}
+
+// accepts returns true if a rec with sortOn key and read sequence seq would be kept.
+func (h *topHeap) accepts(sortOn []byte, seq int) bool {
+	return len(h.recs) < h.top || compareSortRecs(SortRec{SortOn: sortOn, seq: seq}, h.recs[0]) < 0
+}
+
+// add adds rec, replacing the worst kept rec if heap is full. Caller checks accepts first.
+func (h *topHeap) add(rec SortRec) {
+	if len(h.recs) < h.top {
+		heap.Push(h, rec)
+		return
 	}
-	recBytes = parsedRec.MarshalTo(nil)
-	return
+	h.recs[0] = rec
+	heap.Fix(h, 0)
+}
+
+// sorted returns the kept recs in sort order.
+func (h *topHeap) sorted() []SortRec {
+	slices.SortFunc(h.recs, compareSortRecs)
+	return h.recs
 }
 
-// extractSortVals extracts values to be sorted from parsedRec into a slice of strings.
-// Integer values are converted to a string and leading zeros added to ensure all int vals are same length.
-func extractSortVals(parsedRec *fastjson.Value, sortKeys []SortKey) (sortVals []string, bErr *BobbErr) {
-	sortVals = make([]string, 0, len(sortKeys))
-	var sortVal string
+// encodeSortKey appends the sort key of parsedRec to buf and returns it.
+// The key is built so that bytes.Compare gives the SortKeys order, including direction:
+//   - int values are 8 bytes, big endian with sign bit flipped
+//   - numeric expression values are 8 bytes, float64 bits ordered the same way
+//   - str values (plain strings, never contain 0x00 or 0xff) are followed by a 0x00 terminator
+//   - for desc codes, the bytes of the value and terminator are inverted
+func encodeSortKey(buf []byte, parsedRec *fastjson.Value, sortKeys []SortKey) ([]byte, *BobbErr) {
+	var strVal string
 	var intVal int
+	var num float64
+	var bErr *BobbErr
 	for _, sortKey := range sortKeys {
+		start := len(buf)
 		switch {
 		case slices.Contains(StrSortCodes, sortKey.Dir): // Dir contains both direction and fld type
-			sortVal, bErr = parsedRecGetStr(parsedRec, sortKey.Fld, sortKey.UseDefault, StrPlain)
+			if sortKey.expr != nil {
+				strVal, bErr = exprGetStr(parsedRec, sortKey.expr, StrPlain)
+			} else {
+				strVal, bErr = parsedRecGetStr(parsedRec, sortKey.Fld, sortKey.UseDefault, StrPlain)
+			}
+			buf = append(buf, strVal...)
+			buf = append(buf, 0)
+		case slices.Contains(IntSortCodes, sortKey.Dir) && sortKey.expr != nil:
+			num, bErr = exprGetNum(parsedRec, sortKey.expr)
+			bits := math.Float64bits(num)
+			if num < 0 {
+				bits = ^bits // negative floats sort in reverse bit order
+			} else {
+				bits |= 1 << 63
+			}
+			buf = binary.BigEndian.AppendUint64(buf, bits)
 		case slices.Contains(IntSortCodes, sortKey.Dir):
 			intVal, bErr = parsedRecGetInt(parsedRec, sortKey.Fld, sortKey.UseDefault)
-			// note - underscores are ignored in numeric literals, added for readability, negative values will sort correctly with this approach
-			// allows for sorting of int values as strings while preserving numeric order
-			sortVal = fmt.Sprintf("%020d", intVal+1_000_000_000_000_000_000) // converts 3456 to 100000000000003456
+			buf = binary.BigEndian.AppendUint64(buf, uint64(intVal)^(1<<63))
 		default:
 			log.Panicln("invalid sortkey dir", sortKey.Dir) // should already be validated
 		}
 		if bErr != nil {
-			return
+			return buf, bErr
+		}
+		if slices.Contains(DescSortCodes, sortKey.Dir) {
+			for i := start; i < len(buf); i++ {
+				buf[i] = ^buf[i]
+			}
 		}
-		sortVals = append(sortVals, sortVal)
 	}
-	return
+	return buf, nil
 }
 
 // validateFindConditions validates values and loads defaults.
@@ -404,12 +768,61 @@ func validateFindConditions(conditions []FindCondition) ([]FindCondition, error)
 		if !slices.Contains(AllDefaultCodes, condition.UseDefault) {
 			return nil, fmt.Errorf("invalid default code: %s", condition.UseDefault)
 		}
+		if condition.Expr != "" {
+			x, err := parseExpr(condition.Expr)
+			if err != nil {
+				return nil, err
+			}
+			if condition.Op == FindExists || condition.Op == FindIsNull {
+				return nil, fmt.Errorf("Expr cannot be used with op %s", condition.Op)
+			}
+			condition.expr = x
+		}
+		if condition.ValExpr != "" {
+			x, err := parseExpr(condition.ValExpr)
+			if err != nil {
+				return nil, err
+			}
+			switch condition.Op {
+			case FindInStrList, FindInIntList, FindRegex, FindExists, FindIsNull:
+				return nil, fmt.Errorf("ValExpr cannot be used with op %s", condition.Op)
+			}
+			condition.valExpr = x
+		}
+		if condition.SubQry != nil {
+			if err := validateSubQry(condition); err != nil {
+				return nil, err
+			}
+			validatedConditions[i] = condition
+			continue // list values come from SubQry results
+		}
 		if condition.Op == FindInStrList && len(condition.StrList) == 0 {
 			return nil, fmt.Errorf("FindInStrList has empty string list")
 		}
 		if condition.Op == FindInIntList && len(condition.IntList) == 0 {
 			return nil, fmt.Errorf("FindInIntList has empty integer list")
 		}
+		if condition.Op == FindRegex {
+			pattern := condition.ValStr
+			if condition.StrOption != StrAsIs {
+				pattern = "(?i)" + pattern
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid FindRegex pattern for fld %s: %s", condition.Fld, err.Error())
+			}
+			condition.regex = re
+			validatedConditions[i] = condition
+			continue // pattern is not converted by StrOption
+		}
+		if condition.Op == FindFuzzy {
+			if condition.MaxDist < 0 {
+				return nil, fmt.Errorf("FindFuzzy MaxDist cannot be negative, fld %s", condition.Fld)
+			}
+			if condition.MaxDist == 0 {
+				condition.MaxDist = 1
+			}
+		}
 		if condition.StrOption == StrLowerCase {
 			condition.ValStr = strings.ToLower(condition.ValStr)
 			for i, s := range condition.StrList {
@@ -432,6 +845,16 @@ func validateFindConditions(conditions []FindCondition) ([]FindCondition, error)
 func validateSortKeys(sortKeys []SortKey) ([]SortKey, error) {
 	validatedSortKeys := make([]SortKey, len(sortKeys))
 	for i, sortKey := range sortKeys {
+		if sortKey.Expr != "" {
+			x, err := parseExpr(sortKey.Expr)
+			if err != nil {
+				return nil, err
+			}
+			sortKey.expr = x
+			if sortKey.Fld == "" {
+				sortKey.Fld = sortKey.Expr // used in error msgs
+			}
+		}
 		if sortKey.Fld == "" {
 			return nil, fmt.Errorf("SortKey missing Fld")
 		}
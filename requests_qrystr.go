@@ -0,0 +1,842 @@
+package bobb
+
+/*
+QryStrRequest runs a query written as text, compiled to a QryRequest by compileQryStr.
+
+	select id, city from location
+	where st in ('TN', 'KY') and zip startswith '5'
+	order by locationType desc, city
+	limit 50
+
+Clauses, keywords are not case sensitive, clauses after from can be in any order:
+
+	select * | count(*) | fld [as name], ...   optional, fld list sets QryRequest.Fields, count(*) sets CountOnly
+	from bkt                                    required
+	using index indexBkt                        IndexBkt, keys clause then applies to index keys
+	[left] join bkt on keyFld set toFld = fromFld, ...
+	                                            1 Join per toFld, left join sets Join.UseDefault
+	where condition                             Criteria, see below
+	keys from 'a' [to 'b'] | keys to 'b' | keys prefix 'p'
+	                                            StartKey, EndKey
+	reverse                                     Reverse
+	order by fld [asc|desc] [str|int], ...      SortKeys, if str/int not given the fld type of the first bkt rec having the fld is used
+	limit n                                     Top (limit after sort)
+	scanlimit n                                 Limit (limit before sort)
+
+Conditions are combined with and, or, not and parentheses, and converted to FindGroups (or of ands).
+
+	fld = 'x'   fld != 'x'   fld < 'x'   fld <= 'x'   fld > 'x'   fld >= 'x'   string compare (matches, before, after)
+	fld = 5     fld != 5     fld < 5     fld <= 5     fld > 5     fld >= 5     int compare (equals, lessthan, greaterthan)
+	fld [not] in ('a', 'b') | (1, 2)
+	fld [not] between 'a' and 'z' | 1 and 9
+	fld [not] contains | containsword | startswith | endswith | regex 'x'
+	fld [not] fuzzy 'x' [dist n]
+	fld is [not] null
+	fld [not] exists
+
+Flds are names, nested flds use ".", ex. agent.name. A fld that is a keyword can be double quoted, ex. "limit".
+A fld or compare value can be an expression (see expr.go) in backquotes, ex. `qty * price` > 100.
+Comparisons with an expression value are numeric (=, <, >), string ops (contains, ...) compare as strings.
+A join is loaded before the find step if the where clause uses its toFld, otherwise after.
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fastjson"
+	bolt "go.etcd.io/bbolt"
+)
+
+// QryStrRequest compiles Qry (query text, see comments at top of file) to a QryRequest and runs it.
+// Syntax errors return StatusFail with the position and what was expected in Response.Msg.
+type QryStrRequest struct {
+	Qry      string
+	Compile  bool // if true, query is not run, Response.Rec contains json of the compiled QryRequest
+	Explain  bool // same as QryRequest.Explain
+	Parallel int  // same as QryRequest.Parallel
+}
+
+func (req QryStrRequest) IsUpdtReq() bool {
+	return false
+}
+
+func (req *QryStrRequest) Run(tx *bolt.Tx) (*Response, error) {
+
+	resp := new(Response)
+	qry, err := compileQryStr(tx, req.Qry)
+	if err != nil {
+		resp.Status = StatusFail
+		resp.Msg = err.Error()
+		return resp, nil
+	}
+	qry.Explain = req.Explain
+	qry.Parallel = req.Parallel
+	if req.Compile {
+		if resp.Rec, err = json.Marshal(qry); err != nil {
+			resp.Status = StatusFail
+			resp.Msg = "compiled qry json marshal error - " + err.Error()
+			return resp, nil
+		}
+		resp.Status = StatusOk
+		return resp, nil
+	}
+	return qry.Run(tx)
+}
+
+// maxQryStrGroups limits the # of FindGroups a where clause can expand to.
+const maxQryStrGroups = 64
+
+// qryStrInferRecs is the max # of bkt recs read to find the type of an order by fld.
+const qryStrInferRecs = 20
+
+const (
+	qsEOF    = iota
+	qsName   // fld, bkt or keyword
+	qsQuoted // double quoted name, never a keyword
+	qsStr    // single quoted string
+	qsInt    // integer
+	qsExpr   // backquoted expression
+	qsPunct  // ( ) , * = != <> < <= > >=
+)
+
+type qsToken struct {
+	kind int
+	text string // for qsStr and qsExpr, the value without quotes
+	pos  int
+}
+
+func (tok qsToken) String() string {
+	switch tok.kind {
+	case qsEOF:
+		return "end of query"
+	case qsStr:
+		return strconv.Quote(tok.text)
+	case qsExpr:
+		return "`" + tok.text + "`"
+	}
+	return fmt.Sprintf("%q", tok.text)
+}
+
+// qsKeywords cannot be used as unquoted fld or bkt names.
+var qsKeywords = []string{
+	"select", "from", "using", "index", "left", "join", "on", "set", "where", "keys", "prefix", "to",
+	"reverse", "order", "by", "asc", "desc", "str", "int", "limit", "scanlimit", "as",
+	"and", "or", "not", "in", "between", "is", "null", "exists", "dist",
+	FindContains, FindContainsWord, FindStartsWith, FindEndsWith, FindRegex, FindFuzzy,
+}
+
+func qsTokenize(src string) ([]qsToken, error) {
+	toks := make([]qsToken, 0, 32)
+	pos := 0
+	for pos < len(src) {
+		c := src[pos]
+		start := pos
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			pos++
+			continue
+		case c == '\'':
+			var val strings.Builder
+			pos++
+			for {
+				if pos >= len(src) {
+					return nil, fmt.Errorf("qry pos %d: string not terminated, missing '", start)
+				}
+				if src[pos] == '\'' {
+					if pos+1 < len(src) && src[pos+1] == '\'' { // '' is an escaped quote
+						val.WriteByte('\'')
+						pos += 2
+						continue
+					}
+					pos++
+					break
+				}
+				val.WriteByte(src[pos])
+				pos++
+			}
+			toks = append(toks, qsToken{kind: qsStr, text: val.String(), pos: start})
+		case c == '"' || c == '`':
+			end := strings.IndexByte(src[pos+1:], c)
+			if end == -1 {
+				return nil, fmt.Errorf("qry pos %d: missing closing %c", start, c)
+			}
+			kind := qsQuoted
+			if c == '`' {
+				kind = qsExpr
+			}
+			toks = append(toks, qsToken{kind: kind, text: src[pos+1 : pos+1+end], pos: start})
+			pos += end + 2
+		case c >= '0' && c <= '9' || c == '-' && pos+1 < len(src) && src[pos+1] >= '0' && src[pos+1] <= '9':
+			pos++
+			for pos < len(src) && src[pos] >= '0' && src[pos] <= '9' {
+				pos++
+			}
+			if pos < len(src) && (src[pos] == '.' || isNameChar(src[pos])) {
+				return nil, fmt.Errorf("qry pos %d: invalid number %q, only integers are supported", start, src[start:pos+1])
+			}
+			toks = append(toks, qsToken{kind: qsInt, text: src[start:pos], pos: start})
+		case isNameChar(c):
+			for pos < len(src) && (isNameChar(src[pos]) || src[pos] == '.') {
+				pos++
+			}
+			toks = append(toks, qsToken{kind: qsName, text: src[start:pos], pos: start})
+		case strings.HasPrefix(src[pos:], "!=") || strings.HasPrefix(src[pos:], "<>") ||
+			strings.HasPrefix(src[pos:], "<=") || strings.HasPrefix(src[pos:], ">="):
+			toks = append(toks, qsToken{kind: qsPunct, text: src[pos : pos+2], pos: start})
+			pos += 2
+		case strings.IndexByte("(),*=<>", c) >= 0:
+			toks = append(toks, qsToken{kind: qsPunct, text: string(c), pos: start})
+			pos++
+		default:
+			return nil, fmt.Errorf("qry pos %d: unexpected character %q", start, c)
+		}
+	}
+	return append(toks, qsToken{kind: qsEOF, pos: len(src)}), nil
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// qsNode is a node of the parsed where clause.
+type qsNode struct {
+	op   string // "and", "or", "not", or "" for a condition
+	kids []*qsNode
+	cond FindCondition
+}
+
+// qsSort is an order by item, typ is "" if the fld type is to be inferred from bkt recs.
+type qsSort struct {
+	key  SortKey
+	desc bool
+	typ  string
+}
+
+// qsParser is a recursive descent parser, where clause grammar:
+//
+//	or   := and ("or" and)*
+//	and  := not ("and" not)*
+//	not  := "not" not | "(" or ")" | cond
+//	cond := operand compare
+type qsParser struct {
+	toks []qsToken
+	i    int
+
+	qry      QryRequest
+	where    *qsNode
+	sorts    []qsSort
+	joins    []Join
+	clauses  map[string]bool
+	whereUse []string // flds and expressions used in where clause, to place joins
+}
+
+// compileQryStr parses src and returns the equivalent QryRequest.
+// tx is used to infer the type of order by flds, it can be nil (str assumed).
+func compileQryStr(tx *bolt.Tx, src string) (*QryRequest, error) {
+	toks, err := qsTokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &qsParser{toks: toks, clauses: make(map[string]bool)}
+	if err = p.query(); err != nil {
+		return nil, err
+	}
+	qry := p.qry
+	if p.where != nil {
+		if qry.Criteria, err = qsGroups(p.where, false); err != nil {
+			return nil, err
+		}
+	}
+	for _, join := range p.joins {
+		if p.usedInWhere(join.ToFld) {
+			qry.JoinsBeforeFind = append(qry.JoinsBeforeFind, join)
+		} else {
+			qry.JoinsAfterFind = append(qry.JoinsAfterFind, join)
+		}
+	}
+	if len(p.sorts) > 0 {
+		var bkt *bolt.Bucket
+		if tx != nil {
+			bkt = tx.Bucket([]byte(qry.BktName))
+		}
+		for _, sort := range p.sorts {
+			typ := sort.typ
+			if typ == "" {
+				typ = inferFldType(bkt, sort.key.Fld)
+			}
+			switch {
+			case typ == "int" && sort.desc:
+				sort.key.Dir = SortDescInt
+			case typ == "int":
+				sort.key.Dir = SortAscInt
+			case sort.desc:
+				sort.key.Dir = SortDescStr
+			default:
+				sort.key.Dir = SortAscStr
+			}
+			qry.SortKeys = append(qry.SortKeys, sort.key)
+		}
+	}
+	// same validation as QryRequest.Run, so errors refer to the query text
+	if _, err = prepareQry(&qry); err != nil {
+		return nil, fmt.Errorf("qry error - %s", err.Error())
+	}
+	if _, err = newProjection(qry.Fields); err != nil {
+		return nil, fmt.Errorf("qry select error - %s", err.Error())
+	}
+	return &qry, nil
+}
+
+// inferFldType returns "int" if fld is a number in the first bkt rec having fld, otherwise "str".
+func inferFldType(bkt *bolt.Bucket, fld string) string {
+	if bkt == nil {
+		return "str"
+	}
+	parser := parserPool.Get()
+	defer parserPool.Put(parser)
+	path := strings.Split(fld, ".")
+	csr := bkt.Cursor()
+	n := 0
+	for k, v := csr.First(); k != nil && n < qryStrInferRecs; k, v = csr.Next() {
+		n++
+		parsedRec, err := parser.ParseBytes(v)
+		if err != nil {
+			continue
+		}
+		val := parsedRec.Get(path...)
+		if val == nil || val.Type() == fastjson.TypeNull {
+			continue
+		}
+		if val.Type() == fastjson.TypeNumber {
+			return "int"
+		}
+		return "str"
+	}
+	return "str"
+}
+
+func (p *qsParser) usedInWhere(fld string) bool {
+	word := regexp.MustCompile(`(^|[^\w.])` + regexp.QuoteMeta(fld) + `($|[^\w])`)
+	for _, used := range p.whereUse {
+		if used == fld || strings.HasPrefix(used, fld+".") || word.MatchString(used) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *qsParser) peek() qsToken {
+	return p.toks[p.i]
+}
+
+func (p *qsParser) next() qsToken {
+	tok := p.toks[p.i]
+	if tok.kind != qsEOF {
+		p.i++
+	}
+	return tok
+}
+
+func (p *qsParser) errorf(tok qsToken, format string, args ...any) error {
+	return fmt.Errorf("qry syntax error at pos %d, near %s: %s", tok.pos, tok, fmt.Sprintf(format, args...))
+}
+
+// isKeyword returns true if tok is the keyword kw (not case sensitive).
+func isKeyword(tok qsToken, kw string) bool {
+	return tok.kind == qsName && strings.EqualFold(tok.text, kw)
+}
+
+// accept advances and returns true if next token is keyword or punctuation s.
+func (p *qsParser) accept(s string) bool {
+	tok := p.peek()
+	if isKeyword(tok, s) || tok.kind == qsPunct && tok.text == s {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *qsParser) expect(s string) error {
+	if !p.accept(s) {
+		return p.errorf(p.peek(), "expected %s", s)
+	}
+	return nil
+}
+
+// name returns a fld or bkt name, what is used in the error msg if not found.
+func (p *qsParser) name(what string) (string, error) {
+	tok := p.peek()
+	switch {
+	case tok.kind == qsQuoted && tok.text != "":
+		p.i++
+		return tok.text, nil
+	case tok.kind == qsName && !slices.Contains(qsKeywords, strings.ToLower(tok.text)):
+		p.i++
+		return tok.text, nil
+	case tok.kind == qsName:
+		return "", p.errorf(tok, "expected %s, %s is a keyword (double quote names that are keywords)", what, tok.text)
+	}
+	return "", p.errorf(tok, "expected %s", what)
+}
+
+func (p *qsParser) intVal(what string) (int, error) {
+	tok := p.next()
+	if tok.kind != qsInt {
+		return 0, p.errorf(tok, "expected integer %s", what)
+	}
+	n, err := strconv.Atoi(tok.text)
+	if err != nil {
+		return 0, p.errorf(tok, "invalid integer %s", what)
+	}
+	return n, nil
+}
+
+func (p *qsParser) strVal(what string) (string, error) {
+	tok := p.next()
+	if tok.kind != qsStr {
+		return "", p.errorf(tok, "expected quoted string %s", what)
+	}
+	return tok.text, nil
+}
+
+func (p *qsParser) query() error {
+	if p.accept("select") {
+		if err := p.selectList(); err != nil {
+			return err
+		}
+	}
+	if !p.accept("from") {
+		return p.errorf(p.peek(), "expected from (or select) at start of query")
+	}
+	var err error
+	if p.qry.BktName, err = p.name("bkt name after from"); err != nil {
+		return err
+	}
+	for {
+		tok := p.peek()
+		if tok.kind == qsEOF {
+			return nil
+		}
+		clause := strings.ToLower(tok.text)
+		if tok.kind != qsName || !slices.Contains([]string{"using", "left", "join", "where", "keys", "reverse", "order", "limit", "scanlimit"}, clause) {
+			return p.errorf(tok, "expected clause: using index, join, where, keys, reverse, order by, limit, scanlimit")
+		}
+		p.i++
+		if clause == "left" {
+			if err = p.expect("join"); err != nil {
+				return err
+			}
+			clause = "left join"
+		}
+		if clause != "join" && clause != "left join" {
+			if p.clauses[clause] {
+				return p.errorf(tok, "%s clause used more than once", clause)
+			}
+			p.clauses[clause] = true
+		}
+		switch clause {
+		case "using":
+			if err = p.expect("index"); err == nil {
+				p.qry.IndexBkt, err = p.name("index bkt name")
+			}
+		case "join", "left join":
+			err = p.join(clause == "left join")
+		case "where":
+			p.where, err = p.or()
+		case "keys":
+			err = p.keys()
+		case "reverse":
+			p.qry.Reverse = true
+		case "order":
+			if err = p.expect("by"); err == nil {
+				err = p.orderBy()
+			}
+		case "limit":
+			p.qry.Top, err = p.intVal("after limit")
+		case "scanlimit":
+			p.qry.Limit, err = p.intVal("after scanlimit")
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *qsParser) selectList() error {
+	if p.accept("*") {
+		return nil
+	}
+	if isKeyword(p.peek(), "count") && p.toks[p.i+1].text == "(" {
+		p.i += 2
+		if err := p.expect("*"); err != nil {
+			return err
+		}
+		if err := p.expect(")"); err != nil {
+			return err
+		}
+		p.qry.CountOnly = true
+		return nil
+	}
+	for {
+		fld, err := p.name("fld name, * or count(*) after select")
+		if err != nil {
+			return err
+		}
+		if p.accept("as") {
+			alias, err := p.name("name after as")
+			if err != nil {
+				return err
+			}
+			fld = alias + "=" + fld
+		}
+		p.qry.Fields = append(p.qry.Fields, fld)
+		if !p.accept(",") {
+			return nil
+		}
+	}
+}
+
+func (p *qsParser) join(left bool) error {
+	join := Join{UseDefault: left}
+	var err error
+	if join.JoinBkt, err = p.name("join bkt name"); err != nil {
+		return err
+	}
+	if err = p.expect("on"); err != nil {
+		return err
+	}
+	if join.JoinFld, err = p.name("fld containing join key after on"); err != nil {
+		return err
+	}
+	if err = p.expect("set"); err != nil {
+		return err
+	}
+	for {
+		if join.ToFld, err = p.name("fld to load after set"); err != nil {
+			return err
+		}
+		if err = p.expect("="); err != nil {
+			return err
+		}
+		if join.FromFld, err = p.name("join bkt fld after ="); err != nil {
+			return err
+		}
+		p.joins = append(p.joins, join)
+		if !p.accept(",") {
+			return nil
+		}
+	}
+}
+
+func (p *qsParser) keys() error {
+	var err error
+	switch {
+	case p.accept("prefix"):
+		p.qry.StartKey, err = p.strVal("after keys prefix")
+		p.qry.EndKey = p.qry.StartKey
+	case p.accept("from"):
+		if p.qry.StartKey, err = p.strVal("after keys from"); err == nil && p.accept("to") {
+			p.qry.EndKey, err = p.strVal("after to")
+		}
+	case p.accept("to"):
+		p.qry.EndKey, err = p.strVal("after keys to")
+	default:
+		err = p.errorf(p.peek(), "expected prefix, from or to after keys")
+	}
+	return err
+}
+
+func (p *qsParser) orderBy() error {
+	for {
+		var sort qsSort
+		if tok := p.peek(); tok.kind == qsExpr {
+			p.i++
+			sort.key.Expr = tok.text
+			sort.typ = "int" // expressions are usually numeric, use str for string results
+		} else {
+			var err error
+			if sort.key.Fld, err = p.name("fld or `expression` to order by"); err != nil {
+				return err
+			}
+		}
+		if p.accept("desc") {
+			sort.desc = true
+		} else {
+			p.accept("asc")
+		}
+		if p.accept("int") {
+			sort.typ = "int"
+		} else if p.accept("str") {
+			sort.typ = "str"
+		}
+		p.sorts = append(p.sorts, sort)
+		if !p.accept(",") {
+			return nil
+		}
+	}
+}
+
+func (p *qsParser) or() (*qsNode, error) {
+	x, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("or") {
+		y, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		x = &qsNode{op: "or", kids: []*qsNode{x, y}}
+	}
+	return x, nil
+}
+
+func (p *qsParser) and() (*qsNode, error) {
+	x, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("and") {
+		y, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		x = &qsNode{op: "and", kids: []*qsNode{x, y}}
+	}
+	return x, nil
+}
+
+func (p *qsParser) not() (*qsNode, error) {
+	if p.accept("not") {
+		x, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return &qsNode{op: "not", kids: []*qsNode{x}}, nil
+	}
+	if p.accept("(") {
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		return x, nil
+	}
+	return p.cond()
+}
+
+// cond parses a condition, fld or `expression` followed by a compare.
+func (p *qsParser) cond() (*qsNode, error) {
+	var cond FindCondition
+	if tok := p.peek(); tok.kind == qsExpr {
+		p.i++
+		cond.Expr = tok.text
+		p.whereUse = append(p.whereUse, tok.text)
+	} else {
+		var err error
+		if cond.Fld, err = p.name("fld, `expression`, not or ( in where clause"); err != nil {
+			return nil, err
+		}
+		p.whereUse = append(p.whereUse, cond.Fld)
+	}
+	leaf := func(cond FindCondition) *qsNode { return &qsNode{cond: cond} }
+
+	opTok := p.peek()
+	if opTok.kind == qsPunct && opTok.text != "(" && opTok.text != ")" && opTok.text != "," && opTok.text != "*" {
+		p.i++
+		valTok := p.next()
+		isStr := false
+		switch valTok.kind {
+		case qsStr:
+			cond.ValStr, isStr = valTok.text, true
+		case qsInt:
+			cond.ValInt, _ = strconv.Atoi(valTok.text)
+		case qsExpr:
+			cond.ValExpr = valTok.text
+			p.whereUse = append(p.whereUse, valTok.text)
+		default:
+			return nil, p.errorf(valTok, "expected 'string', integer or `expression` after %s", opTok.text)
+		}
+		ops := map[string][2]string{ // op for str value, op for int value
+			"=":  {FindMatches, FindEquals},
+			"!=": {FindMatches, FindEquals},
+			"<>": {FindMatches, FindEquals},
+			"<":  {FindBefore, FindLessThan},
+			">=": {FindBefore, FindLessThan},
+			">":  {FindAfter, FindGreaterThan},
+			"<=": {FindAfter, FindGreaterThan},
+		}
+		findOps := ops[opTok.text]
+		cond.Op = findOps[1]
+		if isStr {
+			cond.Op = findOps[0]
+		}
+		cond.Not = opTok.text == "!=" || opTok.text == "<>" || opTok.text == ">=" || opTok.text == "<="
+		return leaf(cond), nil
+	}
+
+	if p.accept("is") {
+		cond.Op = FindIsNull
+		cond.Not = p.accept("not")
+		if err := p.expect("null"); err != nil {
+			return nil, err
+		}
+		return leaf(cond), nil
+	}
+
+	cond.Not = p.accept("not")
+	tok := p.next()
+	op := strings.ToLower(tok.text)
+	switch {
+	case tok.kind != qsName:
+	case op == "exists":
+		cond.Op = FindExists
+		return leaf(cond), nil
+	case op == "in":
+		return p.inList(cond)
+	case op == "between":
+		return p.between(cond)
+	case slices.Contains([]string{FindContains, FindContainsWord, FindStartsWith, FindEndsWith, FindRegex, FindFuzzy}, op):
+		cond.Op = op
+		if valTok := p.peek(); valTok.kind == qsExpr && op != FindRegex && op != FindFuzzy {
+			p.i++
+			cond.ValExpr = valTok.text
+			p.whereUse = append(p.whereUse, valTok.text)
+		} else {
+			var err error
+			if cond.ValStr, err = p.strVal("after " + op); err != nil {
+				return nil, err
+			}
+		}
+		if op == FindFuzzy && p.accept("dist") {
+			var err error
+			if cond.MaxDist, err = p.intVal("after dist"); err != nil {
+				return nil, err
+			}
+		}
+		return leaf(cond), nil
+	}
+	return nil, p.errorf(tok, "expected compare after %s: = != < <= > >= in between is exists contains containsword startswith endswith regex fuzzy", qsCondSubject(cond))
+}
+
+func qsCondSubject(cond FindCondition) string {
+	if cond.Expr != "" {
+		return "`" + cond.Expr + "`"
+	}
+	return cond.Fld
+}
+
+func (p *qsParser) inList(cond FindCondition) (*qsNode, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.next()
+		switch {
+		case tok.kind == qsStr && len(cond.IntList) == 0:
+			cond.StrList = append(cond.StrList, tok.text)
+		case tok.kind == qsInt && len(cond.StrList) == 0:
+			n, _ := strconv.Atoi(tok.text)
+			cond.IntList = append(cond.IntList, n)
+		case tok.kind == qsStr || tok.kind == qsInt:
+			return nil, p.errorf(tok, "in list values must all be strings or all integers")
+		default:
+			return nil, p.errorf(tok, "expected 'string' or integer in list")
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	cond.Op = FindInIntList
+	if len(cond.StrList) > 0 {
+		cond.Op = FindInStrList
+	}
+	return &qsNode{cond: cond}, nil
+}
+
+// between returns (not (fld < from)) and (not (fld > to)), negated if cond.Not.
+func (p *qsParser) between(cond FindCondition) (*qsNode, error) {
+	from, to := cond, cond
+	from.Not, to.Not = true, true
+	fromTok := p.next()
+	if err := p.expect("and"); err != nil {
+		return nil, err
+	}
+	toTok := p.next()
+	switch {
+	case fromTok.kind == qsStr && toTok.kind == qsStr:
+		from.Op, from.ValStr = FindBefore, fromTok.text
+		to.Op, to.ValStr = FindAfter, toTok.text
+	case fromTok.kind == qsInt && toTok.kind == qsInt:
+		from.Op, to.Op = FindLessThan, FindGreaterThan
+		from.ValInt, _ = strconv.Atoi(fromTok.text)
+		to.ValInt, _ = strconv.Atoi(toTok.text)
+	default:
+		return nil, p.errorf(fromTok, "between values must be 2 strings or 2 integers")
+	}
+	node := &qsNode{op: "and", kids: []*qsNode{{cond: from}, {cond: to}}}
+	if cond.Not {
+		node = &qsNode{op: "not", kids: []*qsNode{node}}
+	}
+	return node, nil
+}
+
+// qsGroups converts the where clause to FindGroups (or of ands), applying not to conditions (De Morgan).
+func qsGroups(node *qsNode, negate bool) ([]FindGroup, error) {
+	op := node.op
+	if negate && op == "and" {
+		op = "or"
+	} else if negate && op == "or" {
+		op = "and"
+	}
+	switch op {
+	case "":
+		cond := node.cond
+		cond.Not = cond.Not != negate
+		return []FindGroup{{cond}}, nil
+	case "not":
+		return qsGroups(node.kids[0], !negate)
+	case "or":
+		x, err := qsGroups(node.kids[0], negate)
+		if err != nil {
+			return nil, err
+		}
+		y, err := qsGroups(node.kids[1], negate)
+		if err != nil {
+			return nil, err
+		}
+		if len(x)+len(y) > maxQryStrGroups {
+			return nil, fmt.Errorf("qry where clause too complex, more than %d and groups", maxQryStrGroups)
+		}
+		return append(x, y...), nil
+	}
+	// and, every group of x combined with every group of y
+	x, err := qsGroups(node.kids[0], negate)
+	if err != nil {
+		return nil, err
+	}
+	y, err := qsGroups(node.kids[1], negate)
+	if err != nil {
+		return nil, err
+	}
+	if len(x)*len(y) > maxQryStrGroups {
+		return nil, fmt.Errorf("qry where clause too complex, more than %d and groups", maxQryStrGroups)
+	}
+	groups := make([]FindGroup, 0, len(x)*len(y))
+	for _, gx := range x {
+		for _, gy := range y {
+			groups = append(groups, slices.Concat(gx, gy))
+		}
+	}
+	return groups, nil
+}
@@ -0,0 +1,254 @@
+package bobb
+
+/*
+SampleRequest returns Count random recs from a bkt or key range, optionally only recs meeting Criteria.
+Used for data quality spot checks and for building test data from copies of production dbs.
+
+Methods:
+  - SampleReservoir (default), every rec in the range is read, each rec meeting Criteria has the same
+    chance of being returned (reservoir sampling, algorithm R)
+  - SampleSeek, for very large bkts, random keys between the 1st and last key of the range are generated
+    and the cursor is positioned at the 1st key >= each one, only sampled recs are read.
+    Not uniform, recs following a large gap in the key space are more likely to be chosen.
+    Up to Count * sampleSeekTries seeks are made, so fewer than Count recs may be returned
+    if Criteria are selective or the range is small.
+
+The same Seed and data return the same sample. If Seed is 0, a random seed is used.
+Response.Rec contains a SampleResult, with the seed used, so the sample can be repeated.
+Recs are returned in key order.
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"slices"
+	"time"
+
+	"github.com/valyala/fastjson"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	SampleReservoir = "reservoir"
+	SampleSeek      = "seek"
+)
+
+const sampleSeekTries = 10 // seek method, max seeks per requested rec
+
+type SampleRequest struct {
+	BktName  string
+	StartKey string      // if not "", keys >= this value
+	EndKey   string      // if not "", keys <= this value, if equal to StartKey, keys beginning with StartKey
+	Count    int         // # of recs returned, required
+	Criteria []FindGroup // optional, only recs meeting Criteria are sampled
+	Method   string      // SampleReservoir (default) or SampleSeek, see comments at top of file
+	Seed     int64       // random seed, if 0 a random seed is used
+	Fields   []string    // optional, return only these flds, see projection.go
+	ErrLimit int         // run stops when ErrLimit exceeded, default 0, settings.MaxErrs limit if -1
+}
+
+// SampleResult is returned in Response.Rec of a SampleRequest.
+type SampleResult struct {
+	Seed    int64  // seed used, pass in SampleRequest.Seed to get the same sample
+	Method  string // method used
+	Scanned int    // # of recs read, all recs in range for reservoir, 1 per seek for seek
+	Matched int    // # of scanned recs meeting Criteria
+}
+
+func (req SampleRequest) IsUpdtReq() bool {
+	return false
+}
+
+// sampleRec is a rec chosen by a SampleRequest.
+type sampleRec struct {
+	key []byte
+	val []byte
+}
+
+func (req *SampleRequest) Run(tx *bolt.Tx) (*Response, error) {
+
+	resp := new(Response)
+	bkt := openBkt(tx, resp, req.BktName)
+	if bkt == nil {
+		return resp, nil
+	}
+	if req.Count < 1 {
+		resp.Status = StatusFail
+		resp.Msg = "Count must be > 0"
+		return resp, nil
+	}
+	if req.Method == "" {
+		req.Method = SampleReservoir
+	}
+	if req.Method != SampleReservoir && req.Method != SampleSeek {
+		resp.Status = StatusFail
+		resp.Msg = "invalid Method- " + req.Method
+		return resp, nil
+	}
+	criteria := make([]FindGroup, len(req.Criteria))
+	var subErrs []BobbErr // Errs of subqueries with StatusWarning, not counted for ErrLimit
+	var err error
+	for i, group := range req.Criteria {
+		if criteria[i], err = validateFindConditions(group); err != nil {
+			resp.Status = StatusFail
+			resp.Msg = fmt.Sprintf("invalid Criteria group %d - %s", i, err.Error())
+			return resp, nil
+		}
+	}
+	if criteria, err = resolveSubQrys(tx, criteria, &subErrs); err != nil { // see subqry.go
+		resp.Status = StatusFail
+		resp.Msg = "invalid Criteria- " + err.Error()
+		return resp, nil
+	}
+	proj, err := newProjection(req.Fields)
+	if err != nil {
+		resp.Status = StatusFail
+		resp.Msg = "invalid Fields- " + err.Error()
+		return resp, nil
+	}
+	if req.ErrLimit == -1 { // see server/bobb_settings.json for MaxErrs value (defined in util.go)
+		req.ErrLimit = MaxErrs
+	}
+
+	result := SampleResult{Seed: req.Seed, Method: req.Method}
+	if result.Seed == 0 {
+		result.Seed = time.Now().UnixNano()
+	}
+	s := &sampler{req: req, criteria: criteria, rnd: rand.New(rand.NewSource(result.Seed)), resp: resp, result: &result}
+	s.parser = parserPool.Get()
+	defer parserPool.Put(s.parser)
+
+	readLoop := NewReadLoop(bkt, nil)
+	if req.Method == SampleSeek {
+		s.seek(readLoop)
+	} else {
+		s.reservoir(readLoop)
+	}
+	if len(resp.Errs) > req.ErrLimit {
+		resp.Errs = resp.Errs[:req.ErrLimit+1]
+		resp.Status = StatusFail
+		resp.Msg = "too many errors, see resp.Errs for details"
+		return resp, nil
+	}
+
+	slices.SortFunc(s.recs, func(a, b sampleRec) int { return bytes.Compare(a.key, b.key) })
+	resp.Recs = make([][]byte, len(s.recs))
+	for i, rec := range s.recs {
+		resp.Recs[i] = rec.val
+		if proj != nil {
+			if resp.Recs[i], err = proj.applyBytes(s.parser, rec.val); err != nil {
+				resp.Errs = append(resp.Errs, *e(ErrParseRec, err.Error(), rec.key, nil))
+			}
+		}
+	}
+	resp.GetCnt = len(resp.Recs)
+	if resp.Rec, err = json.Marshal(result); err != nil {
+		return resp, err
+	}
+	resp.Errs = append(resp.Errs, subErrs...)
+	if len(resp.Errs) > 0 {
+		resp.Status = StatusWarning
+		resp.Msg = "see resp.Errs for details"
+	} else {
+		resp.Status = StatusOk
+	}
+	return resp, nil
+}
+
+// sampler holds the state of 1 SampleRequest run.
+type sampler struct {
+	req      *SampleRequest
+	criteria []FindGroup
+	rnd      *rand.Rand
+	parser   *fastjson.Parser
+	resp     *Response
+	result   *SampleResult
+	recs     []sampleRec
+}
+
+// meets returns true if the rec meets criteria, errors are added to resp.Errs.
+func (s *sampler) meets(k, v []byte) bool {
+	s.result.Scanned++
+	keep := len(s.criteria) == 0
+	if !keep {
+		parsedRec, err := s.parser.ParseBytes(v)
+		if err != nil {
+			s.resp.Errs = append(s.resp.Errs, *e(ErrParseRec, err.Error(), k, v))
+			return false
+		}
+		var bErr *BobbErr
+		for _, findGroup := range s.criteria {
+			if keep, bErr = parsedRecFind(parsedRec, findGroup); bErr != nil || keep {
+				break
+			}
+		}
+		if bErr != nil {
+			bErr.Key, bErr.Val = k, v
+			s.resp.Errs = append(s.resp.Errs, *bErr)
+			return false
+		}
+	}
+	if keep {
+		s.result.Matched++
+	}
+	return keep
+}
+
+// reservoir reads every rec in range, the i-th matching rec replaces a random sampled rec with probability Count/i.
+func (s *sampler) reservoir(readLoop *ReadLoop) {
+	k, v, _ := readLoop.Start(s.req.StartKey, s.req.EndKey, 0)
+	for ; k != nil && len(s.resp.Errs) <= s.req.ErrLimit; k, v, _ = readLoop.Next() {
+		if !s.meets(k, v) {
+			continue
+		}
+		if len(s.recs) < s.req.Count {
+			s.recs = append(s.recs, sampleRec{key: k, val: v})
+		} else if j := s.rnd.Intn(s.result.Matched); j < s.req.Count {
+			s.recs[j] = sampleRec{key: k, val: v}
+		}
+	}
+}
+
+// seek positions the cursor at random keys between the 1st and last key of the range.
+func (s *sampler) seek(readLoop *ReadLoop) {
+	first, _, _ := readLoop.Start(s.req.StartKey, s.req.EndKey, 0)
+	if first == nil {
+		return
+	}
+	first = bytes.Clone(first)
+	readLoop.Reverse = true
+	last, _, _ := readLoop.Start(s.req.StartKey, s.req.EndKey, 0)
+
+	// keys as big endian numbers of the same length, random key is lo + random n, n in [0, hi-lo]
+	width := max(len(first), len(last))
+	lo := new(big.Int).SetBytes(padRight(first, width))
+	span := new(big.Int).SetBytes(padRight(last, width))
+	span.Sub(span, lo).Add(span, big.NewInt(1))
+
+	csr := readLoop.Csr
+	seen := make(map[string]bool)
+	n := new(big.Int)
+	seekKey := make([]byte, width)
+	for try := 0; try < s.req.Count*sampleSeekTries && len(s.recs) < s.req.Count && len(s.resp.Errs) <= s.req.ErrLimit; try++ {
+		n.Rand(s.rnd, span).Add(n, lo)
+		// trailing 0s trimmed, so padded first key seeks to first, k is <= last as seekKey <= padded last
+		k, v := csr.Seek(bytes.TrimRight(n.FillBytes(seekKey), "\x00"))
+		if k == nil || seen[string(k)] {
+			continue
+		}
+		seen[string(k)] = true
+		if s.meets(k, v) {
+			s.recs = append(s.recs, sampleRec{key: k, val: v})
+		}
+	}
+}
+
+// padRight returns key extended with 0 bytes to width.
+func padRight(key []byte, width int) []byte {
+	padded := make([]byte, width)
+	copy(padded, key)
+	return padded
+}
@@ -0,0 +1,441 @@
+package bobb
+
+/*
+Saved queries are QryRequest templates stored in the saved_queries bkt under a name.
+A template is the json of a QryRequest where any json string value that is exactly "$" + param name
+is a placeholder, replaced by the param value when the query is run. Placeholders can be used for
+any QryRequest value, ex. FindCondition ValStr, ValInt, StrList, StartKey, Top, because the value is
+inserted as typed json ("$minType" becomes 3, not "3").
+
+	{"BktName": "location",
+	 "Criteria": [[{"Fld": "st", "Op": "matches", "ValStr": "$state"},
+	               {"Fld": "locationType", "Op": "greaterthan", "ValInt": "$minType"}]]}
+
+RunSavedQueryRequest binds params, checks them against the declared QryParam types, and runs the query.
+The validated Criteria and SortKeys (regex and expressions compiled) are cached by saved query and bound
+param values, see savedQryCache. A cache entry is used only if the saved_queries bkt value it was built
+from is unchanged, so save and delete (update trans) never leave stale entries in use.
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const SavedQueriesBkt = "saved_queries"
+
+// SavedQueryRequest Operation codes
+const (
+	SavedQuerySave   = "save"   // add or replace Query
+	SavedQueryGet    = "get"    // Response.Rec contains json of SavedQuery Name
+	SavedQueryList   = "list"   // Response.Recs contains json of all SavedQuery, in name order
+	SavedQueryDelete = "delete" // delete SavedQuery Name
+)
+
+// QryParam Type codes
+const (
+	QryParamStr     = "string"
+	QryParamInt     = "int"
+	QryParamStrList = "strlist"
+	QryParamIntList = "intlist"
+)
+
+var AllQryParamTypes = []string{QryParamStr, QryParamInt, QryParamStrList, QryParamIntList}
+
+var qryParamName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// maxPreparedPerQry limits the # of bound param sets cached for each saved query.
+const maxPreparedPerQry = 100
+
+// QryParam declares a placeholder used in a SavedQuery template.
+type QryParam struct {
+	Name     string // placeholder in template is "$" + Name
+	Type     string // see QryParam* codes
+	Required bool   // if false and param not in RunSavedQueryRequest.Params, Default is used
+	Default  any    // must match Type, if nil the zero value of Type is used
+}
+
+// SavedQuery is stored in the saved_queries bkt, key is Name.
+type SavedQuery struct {
+	Name        string
+	Description string
+	Params      []QryParam
+	Qry         json.RawMessage // QryRequest template json, see comments at top of file
+}
+
+// SavedQueryRequest saves, gets, lists or deletes saved queries.
+// For SavedQuerySave, the template is validated by binding the param defaults and validating the resulting QryRequest.
+type SavedQueryRequest struct {
+	Operation string     // see SavedQuery* codes
+	Name      string     // used by get and delete
+	Query     SavedQuery // used by save
+}
+
+func (req SavedQueryRequest) IsUpdtReq() bool {
+	op := strings.ToLower(req.Operation)
+	return op == SavedQuerySave || op == SavedQueryDelete
+}
+
+func (req *SavedQueryRequest) Run(tx *bolt.Tx) (*Response, error) {
+
+	resp := new(Response)
+	op := strings.ToLower(req.Operation)
+	switch op {
+	case SavedQuerySave:
+		query := req.Query
+		if _, err := newSavedQryEntry(query); err != nil {
+			resp.Status = StatusFail
+			resp.Msg = "invalid saved query - " + err.Error()
+			return resp, nil
+		}
+		bkt := openBkt(tx, resp, SavedQueriesBkt, CreateIfNotExists)
+		if bkt == nil {
+			return resp, nil
+		}
+		val, err := json.Marshal(query)
+		if err == nil {
+			err = bkt.Put([]byte(query.Name), val)
+		}
+		if err != nil {
+			log.Println("db error - save query failed", query.Name, err)
+			resp.Status = StatusFail
+			resp.Msg = "save query failed - " + err.Error()
+			return resp, err // trans will be rolled back
+		}
+		resp.PutCnt = 1
+	case SavedQueryGet:
+		bkt := tx.Bucket([]byte(SavedQueriesBkt))
+		var val []byte
+		if bkt != nil {
+			val = bkt.Get([]byte(req.Name))
+		}
+		if val == nil {
+			resp.Status = StatusFail
+			resp.Msg = "saved query not found - " + req.Name
+			return resp, nil
+		}
+		resp.Rec = val // view trans, response written inside trans
+		resp.GetCnt = 1
+	case SavedQueryList:
+		resp.Recs = make([][]byte, 0, 10)
+		if bkt := tx.Bucket([]byte(SavedQueriesBkt)); bkt != nil {
+			bkt.ForEach(func(k, v []byte) error {
+				resp.Recs = append(resp.Recs, v)
+				return nil
+			})
+		}
+		resp.GetCnt = len(resp.Recs)
+	case SavedQueryDelete:
+		bkt := tx.Bucket([]byte(SavedQueriesBkt))
+		if bkt == nil || bkt.Get([]byte(req.Name)) == nil {
+			resp.Status = StatusFail
+			resp.Msg = "saved query not found - " + req.Name
+			return resp, nil
+		}
+		if err := bkt.Delete([]byte(req.Name)); err != nil {
+			log.Println("db error - delete saved query failed", req.Name, err)
+			resp.Status = StatusFail
+			resp.Msg = "delete saved query failed - " + err.Error()
+			return resp, err
+		}
+		savedQryCache.remove(req.Name)
+	default:
+		resp.Status = StatusFail
+		resp.Msg = "Invalid SavedQuery Operation-" + op
+		return resp, nil
+	}
+	resp.Status = StatusOk
+	return resp, nil
+}
+
+// RunSavedQueryRequest runs the saved query Name with Params bound to its placeholders.
+// Params keys are param names without "$". Response is the same as QryRequest.
+type RunSavedQueryRequest struct {
+	Name    string
+	Params  map[string]any
+	Explain bool // same as QryRequest.Explain
+}
+
+func (req RunSavedQueryRequest) IsUpdtReq() bool {
+	return false
+}
+
+func (req *RunSavedQueryRequest) Run(tx *bolt.Tx) (*Response, error) {
+
+	resp := new(Response)
+	bkt := tx.Bucket([]byte(SavedQueriesBkt))
+	var val []byte
+	if bkt != nil {
+		val = bkt.Get([]byte(req.Name))
+	}
+	if val == nil {
+		resp.Status = StatusFail
+		resp.Msg = "saved query not found - " + req.Name
+		return resp, nil
+	}
+	entry, err := savedQryCache.get(req.Name, val)
+	if err != nil {
+		resp.Status = StatusFail
+		resp.Msg = fmt.Sprintf("saved query %s invalid - %s", req.Name, err.Error())
+		return resp, nil
+	}
+	qry, err := entry.bind(req.Params)
+	if err != nil {
+		resp.Status = StatusFail
+		resp.Msg = fmt.Sprintf("saved query %s - %s", req.Name, err.Error())
+		return resp, nil
+	}
+	qry.Explain = qry.Explain || req.Explain
+	return qry.Run(tx)
+}
+
+// savedQryEntry is the decoded form of a saved_queries bkt value.
+type savedQryEntry struct {
+	raw      []byte // bkt value entry was built from, copied out of bolt
+	query    SavedQuery
+	params   map[string]QryParam
+	template any // decoded Qry template
+
+	mu       sync.Mutex
+	prepared map[string]*QryRequest // bound QryRequest with prepared set, by bound params json
+}
+
+// newSavedQryEntry validates query and decodes its template.
+// Every placeholder must be declared and every declared param used.
+// The template is bound with param defaults to check the result is a valid QryRequest.
+func newSavedQryEntry(query SavedQuery) (*savedQryEntry, error) {
+	if query.Name == "" {
+		return nil, fmt.Errorf("Name not specified")
+	}
+	if len(query.Qry) == 0 {
+		return nil, fmt.Errorf("Qry template not specified")
+	}
+	entry := &savedQryEntry{query: query, params: make(map[string]QryParam), prepared: make(map[string]*QryRequest)}
+	for _, param := range query.Params {
+		if !qryParamName.MatchString(param.Name) {
+			return nil, fmt.Errorf("invalid param name %q", param.Name)
+		}
+		if _, found := entry.params[param.Name]; found {
+			return nil, fmt.Errorf("param %s declared more than once", param.Name)
+		}
+		if !slices.Contains(AllQryParamTypes, param.Type) {
+			return nil, fmt.Errorf("invalid type %q for param %s", param.Type, param.Name)
+		}
+		if param.Default != nil {
+			if err := checkParamType(param, param.Default); err != nil {
+				return nil, fmt.Errorf("Default - %s", err.Error())
+			}
+		}
+		entry.params[param.Name] = param
+	}
+	if err := json.Unmarshal(query.Qry, &entry.template); err != nil {
+		return nil, fmt.Errorf("Qry template json error - %s", err.Error())
+	}
+	used := make(map[string]bool)
+	var undeclared string
+	walkPlaceholders(entry.template, func(name string) any {
+		if _, found := entry.params[name]; !found && undeclared == "" {
+			undeclared = name
+		}
+		used[name] = true
+		return nil
+	})
+	if undeclared != "" {
+		return nil, fmt.Errorf("placeholder $%s not declared in Params", undeclared)
+	}
+	for _, param := range query.Params {
+		if !used[param.Name] {
+			return nil, fmt.Errorf("param %s not used in Qry template", param.Name)
+		}
+	}
+	values := make(map[string]any, len(query.Params))
+	for _, param := range query.Params {
+		values[param.Name] = paramDefault(param)
+	}
+	if _, err := entry.prepare(values); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// bind checks params against the declared types, loads defaults, and returns the bound QryRequest.
+// The result is a copy of the cached request, so the caller can change it.
+func (entry *savedQryEntry) bind(params map[string]any) (*QryRequest, error) {
+	values := make(map[string]any, len(entry.params))
+	for name, val := range params {
+		param, found := entry.params[name]
+		if !found {
+			return nil, fmt.Errorf("param %s not declared", name)
+		}
+		if err := checkParamType(param, val); err != nil {
+			return nil, err
+		}
+		values[name] = val
+	}
+	for name, param := range entry.params {
+		if _, found := values[name]; found {
+			continue
+		}
+		if param.Required {
+			return nil, fmt.Errorf("required param %s not specified", name)
+		}
+		values[name] = paramDefault(param)
+	}
+	qry, err := entry.prepare(values)
+	if err != nil {
+		return nil, err
+	}
+	qryCopy := *qry
+	return &qryCopy, nil
+}
+
+// prepare returns the QryRequest for values, from the entry cache or by binding the template and validating it.
+func (entry *savedQryEntry) prepare(values map[string]any) (*QryRequest, error) {
+	cacheKey, err := json.Marshal(values) // map keys are sorted by json.Marshal
+	if err != nil {
+		return nil, err
+	}
+	entry.mu.Lock()
+	qry := entry.prepared[string(cacheKey)]
+	entry.mu.Unlock()
+	if qry != nil {
+		return qry, nil
+	}
+
+	bound := walkPlaceholders(entry.template, func(name string) any {
+		return values[name]
+	})
+	qryJson, err := json.Marshal(bound)
+	if err != nil {
+		return nil, err
+	}
+	qry = new(QryRequest)
+	if err = json.Unmarshal(qryJson, qry); err != nil {
+		return nil, fmt.Errorf("bound Qry is not a valid QryRequest - %s", err.Error())
+	}
+	if qry.prepared, err = prepareQry(qry); err != nil {
+		return nil, err
+	}
+
+	entry.mu.Lock()
+	if len(entry.prepared) >= maxPreparedPerQry {
+		clear(entry.prepared)
+	}
+	entry.prepared[string(cacheKey)] = qry
+	entry.mu.Unlock()
+	return qry, nil
+}
+
+// walkPlaceholders returns a copy of template with each placeholder string replaced by replace(name).
+func walkPlaceholders(template any, replace func(name string) any) any {
+	switch val := template.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(val))
+		for k, v := range val {
+			result[k] = walkPlaceholders(v, replace)
+		}
+		return result
+	case []any:
+		result := make([]any, len(val))
+		for i, v := range val {
+			result[i] = walkPlaceholders(v, replace)
+		}
+		return result
+	case string:
+		if name, found := strings.CutPrefix(val, "$"); found && qryParamName.MatchString(name) {
+			return replace(name)
+		}
+	}
+	return template
+}
+
+// checkParamType returns an error if val, decoded from json, does not match param.Type.
+func checkParamType(param QryParam, val any) error {
+	isInt := func(v any) bool {
+		num, ok := v.(float64)
+		return ok && num == math.Trunc(num)
+	}
+	isStr := func(v any) bool {
+		_, ok := v.(string)
+		return ok
+	}
+	valid := false
+	switch param.Type {
+	case QryParamStr:
+		valid = isStr(val)
+	case QryParamInt:
+		valid = isInt(val)
+	case QryParamStrList, QryParamIntList:
+		list, ok := val.([]any)
+		valid = ok
+		for _, item := range list {
+			if (param.Type == QryParamStrList && !isStr(item)) || (param.Type == QryParamIntList && !isInt(item)) {
+				valid = false
+			}
+		}
+	}
+	if !valid {
+		return fmt.Errorf("param %s value %v is not type %s", param.Name, val, param.Type)
+	}
+	return nil
+}
+
+// paramDefault returns param.Default, or the zero value of param.Type if no Default.
+func paramDefault(param QryParam) any {
+	if param.Default != nil {
+		return param.Default
+	}
+	switch param.Type {
+	case QryParamInt:
+		return float64(0)
+	case QryParamStrList, QryParamIntList:
+		return []any{}
+	}
+	return ""
+}
+
+// savedQryCache holds decoded saved queries by name.
+var savedQryCache = &savedQryCacheMap{entries: make(map[string]*savedQryEntry)}
+
+type savedQryCacheMap struct {
+	mu      sync.Mutex
+	entries map[string]*savedQryEntry
+}
+
+// get returns the entry for name, rebuilt if val (current bkt value) differs from the value it was built from.
+func (c *savedQryCacheMap) get(name string, val []byte) (*savedQryEntry, error) {
+	c.mu.Lock()
+	entry := c.entries[name]
+	c.mu.Unlock()
+	if entry != nil && bytes.Equal(entry.raw, val) {
+		return entry, nil
+	}
+	var query SavedQuery
+	if err := json.Unmarshal(val, &query); err != nil {
+		return nil, err
+	}
+	entry, err := newSavedQryEntry(query)
+	if err != nil {
+		return nil, err
+	}
+	entry.raw = bytes.Clone(val)
+	c.mu.Lock()
+	c.entries[name] = entry
+	c.mu.Unlock()
+	return entry, nil
+}
+
+func (c *savedQryCacheMap) remove(name string) {
+	c.mu.Lock()
+	delete(c.entries, name)
+	c.mu.Unlock()
+}
@@ -0,0 +1,309 @@
+package bobb
+
+/*
+Text indexes and TextSearchRequest.
+
+A text index is defined by an IndexSetting with IndexType IndexTypeText (see requests_index.go).
+PutRequest splits the TextFlds values of each record into words and adds an index entry for each word.
+DeleteRequest removes the entries. IndexRequest builds the entries for records already in the data bkt.
+See Indexr.runText in indexr.go.
+
+Index entry key is word|dataKey, value is the word positions in the record, ex. "3,17".
+Words are lowercase letters and digits, punctuation is removed, and common suffixes are
+stripped (see stemWord), so "Inspections," and "inspection" are the same word.
+*/
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/valyala/fastjson"
+	bolt "go.etcd.io/bbolt"
+)
+
+const textKeySeparator = "|" // separates word and data key in text index keys, words never contain it
+
+// notTextIndex returns an error if indexBkt has a text index setting.
+// Text index values are word positions, not data keys, so it can only be read by TextSearchRequest.
+func notTextIndex(tx *bolt.Tx, indexBkt string) error {
+	setting, err := getIndexSetting(tx, indexBkt)
+	if err == nil && setting.IndexType == IndexTypeText {
+		return fmt.Errorf("index %s is a text index, use TextSearchRequest", indexBkt)
+	}
+	return nil // not text index or no setting, index bkts created by IndexRequest or PutIndexRequest have no setting
+}
+
+// textTokens splits text into lowercase words, removing punctuation, and stems each word.
+// Apostrophes are removed rather than splitting the word, ex. "doesn't" > "doesnt".
+func textTokens(text string) []string {
+	text = strings.NewReplacer("'", "", "’", "").Replace(strings.ToLower(text))
+	words := strings.FieldsFunc(text, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+	for i, word := range words {
+		words[i] = stemWord(word)
+	}
+	return words
+}
+
+// stemWord removes common english suffixes so word variations match.
+// Ex. inspections > inspection, repaired > repair, parties > party, testing > test.
+// This is a light stemmer, it does not handle irregular forms.
+func stemWord(word string) string {
+	n := len(word)
+	switch {
+	case n <= 3:
+		return word
+	case strings.HasSuffix(word, "ies") && n > 4:
+		return word[:n-3] + "y"
+	case strings.HasSuffix(word, "sses"):
+		return word[:n-2]
+	case strings.HasSuffix(word, "ing") && n > 5:
+		return word[:n-3]
+	case strings.HasSuffix(word, "ed") && n > 5:
+		return word[:n-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		return word[:n-1]
+	}
+	return word
+}
+
+// textPositions returns the positions of each word in the text flds of parsedRec.
+// Positions continue across flds with a gap, so a phrase cannot span 2 flds.
+// Flds not found or null are skipped.
+func textPositions(parsedRec *fastjson.Value, textFlds []string) (map[string][]int, error) {
+	positions := make(map[string][]int)
+	pos := 0
+	for _, fld := range textFlds {
+		text, bErr := parsedRecGetStr(parsedRec, fld, DefaultAlways)
+		if bErr != nil {
+			return nil, fmt.Errorf("text fld %s, %s", fld, bErr.Msg)
+		}
+		for _, word := range textTokens(text) {
+			positions[word] = append(positions[word], pos)
+			pos++
+		}
+		pos++ // gap between flds
+	}
+	return positions, nil
+}
+
+// formatPositions converts word positions to index entry value, ex. "3,17".
+func formatPositions(positions []int) string {
+	strPositions := make([]string, len(positions))
+	for i, pos := range positions {
+		strPositions[i] = strconv.Itoa(pos)
+	}
+	return strings.Join(strPositions, ",")
+}
+
+// parsePositions converts index entry value back to word positions.
+func parsePositions(val []byte) []int {
+	strPositions := strings.Split(string(val), ",")
+	positions := make([]int, 0, len(strPositions))
+	for _, strPos := range strPositions {
+		if pos, err := strconv.Atoi(strPos); err == nil {
+			positions = append(positions, pos)
+		}
+	}
+	return positions
+}
+
+// postings maps data key to word positions for 1 search term.
+type postings map[string][]int
+
+// loadPostings reads the text index entries for a term.
+// If term ends with "*", it is a prefix term and entries for all words beginning with term are merged.
+func loadPostings(indexBkt *bolt.Bucket, term string) postings {
+	if word, isPrefix := strings.CutSuffix(term, "*"); isPrefix {
+		return scanPostings(indexBkt, PlainString(word)) // prefix terms are not stemmed, "insp*" matches inspect, inspection
+	}
+	words := textTokens(term)
+	if len(words) == 0 {
+		return postings{}
+	}
+	return scanPostings(indexBkt, words[0]+textKeySeparator)
+}
+
+// scanPostings returns the postings of the index keys beginning with prefix, prefix is not stemmed.
+func scanPostings(indexBkt *bolt.Bucket, prefix string) postings {
+	result := make(postings)
+	csr := indexBkt.Cursor()
+	for k, v := csr.Seek([]byte(prefix)); k != nil && strings.HasPrefix(string(k), prefix); k, v = csr.Next() {
+		_, dataKey, _ := strings.Cut(string(k), textKeySeparator)
+		result[dataKey] = append(result[dataKey], parsePositions(v)...)
+	}
+	return result
+}
+
+// phrasePostings returns the data keys containing all words of the phrase in order.
+// Returned positions are the phrase start positions.
+func phrasePostings(indexBkt *bolt.Bucket, phrase string) (result postings, words []postings) {
+	result = make(postings)
+	for _, word := range textTokens(phrase) { // already stemmed, stemming again can change the word, hearing > hear
+		words = append(words, scanPostings(indexBkt, word+textKeySeparator))
+	}
+	if len(words) == 0 {
+		return
+	}
+	for dataKey, firstPositions := range words[0] {
+	nextStart:
+		for _, start := range firstPositions {
+			for i := 1; i < len(words); i++ {
+				if !slices.Contains(words[i][dataKey], start+i) {
+					continue nextStart
+				}
+			}
+			result[dataKey] = append(result[dataKey], start)
+		}
+	}
+	return
+}
+
+// TextSearchRequest returns records from the data bkt of a text index that match the search terms.
+// Records must contain all AllTerms, all Phrases and, if AnyTerms is not empty, at least 1 of AnyTerms.
+// Terms ending with "*" are prefix terms, ex. "insp*" matches inspect, inspection, inspector.
+// Phrases match words in order, ex. "urgent repair".
+// Results are ranked by relevance (tf-idf of matched terms), highest first. Ties are in data key order.
+// Response.GetCnt is the # of records returned, Limit controls the max.
+type TextSearchRequest struct {
+	IndexBkt string   // name of text index bkt, IndexSetting.DataBkt is the data bkt
+	AllTerms []string // AND, records must contain all of these terms
+	AnyTerms []string // OR, records must contain at least 1 of these terms
+	Phrases  []string // records must contain each phrase
+	Limit    int      // max # recs to return, 0 means no limit
+	ErrLimit int      // run stops when ErrLimit exceeded, default 0, settings.MaxErrs limit if -1
+}
+
+func (req TextSearchRequest) IsUpdtReq() bool {
+	return false
+}
+
+func (req *TextSearchRequest) Run(tx *bolt.Tx) (*Response, error) {
+
+	resp := new(Response)
+	setting, err := getIndexSetting(tx, req.IndexBkt)
+	if err != nil {
+		resp.Status = StatusFail
+		resp.Msg = err.Error()
+		return resp, nil
+	}
+	if setting.IndexType != IndexTypeText {
+		resp.Status = StatusFail
+		resp.Msg = fmt.Sprintf("index %s is not a text index", req.IndexBkt)
+		return resp, nil
+	}
+	if len(req.AllTerms) == 0 && len(req.AnyTerms) == 0 && len(req.Phrases) == 0 {
+		resp.Status = StatusFail
+		resp.Msg = "no search terms in request"
+		return resp, nil
+	}
+	dataBkt := openBkt(tx, resp, setting.DataBkt)
+	if dataBkt == nil {
+		return resp, nil
+	}
+	indexBkt := openBkt(tx, resp, req.IndexBkt)
+	if indexBkt == nil {
+		return resp, nil
+	}
+	if req.ErrLimit == -1 { // see server/bobb_settings.json for MaxErrs value (defined in util.go)
+		req.ErrLimit = MaxErrs
+	}
+
+	// docCount is used for idf, # of data keys in text index, kept by Indexr in inverted bkt Sequence
+	var docCount int
+	if invertedBkt := tx.Bucket([]byte(req.IndexBkt + "_inverted")); invertedBkt != nil {
+		docCount = int(invertedBkt.Sequence())
+	}
+	scores := make(map[string]float64)
+	addScores := func(p postings) {
+		idf := math.Log(1 + float64(docCount)/float64(len(p)+1))
+		for dataKey, positions := range p {
+			scores[dataKey] += float64(len(positions)) * idf
+		}
+	}
+	var matches map[string]bool // nil until first condition, then keys meeting all conditions so far
+	intersect := func(p postings) {
+		if matches == nil {
+			matches = make(map[string]bool, len(p))
+			for dataKey := range p {
+				matches[dataKey] = true
+			}
+			return
+		}
+		for dataKey := range matches {
+			if _, found := p[dataKey]; !found {
+				delete(matches, dataKey)
+			}
+		}
+	}
+
+	for _, term := range req.AllTerms {
+		p := loadPostings(indexBkt, term)
+		addScores(p)
+		intersect(p)
+	}
+	for _, phrase := range req.Phrases {
+		p, words := phrasePostings(indexBkt, phrase)
+		for _, wordPostings := range words {
+			addScores(wordPostings)
+		}
+		intersect(p)
+	}
+	if len(req.AnyTerms) > 0 {
+		anyMatch := make(postings)
+		for _, term := range req.AnyTerms {
+			p := loadPostings(indexBkt, term)
+			addScores(p)
+			for dataKey, positions := range p {
+				anyMatch[dataKey] = append(anyMatch[dataKey], positions...)
+			}
+		}
+		intersect(anyMatch)
+	}
+
+	dataKeys := make([]string, 0, len(matches))
+	for dataKey := range matches {
+		dataKeys = append(dataKeys, dataKey)
+	}
+	slices.SortFunc(dataKeys, func(a, b string) int {
+		if scores[a] > scores[b] {
+			return -1
+		}
+		if scores[a] < scores[b] {
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	if req.Limit > 0 && len(dataKeys) > req.Limit {
+		dataKeys = dataKeys[:req.Limit]
+	}
+
+	resp.Recs = make([][]byte, 0, len(dataKeys))
+	for _, dataKey := range dataKeys {
+		v := dataBkt.Get([]byte(dataKey))
+		if v == nil {
+			emsg := fmt.Sprintf("text index data key %s not key in data bkt", dataKey)
+			resp.Errs = append(resp.Errs, *e(ErrIndexRef, emsg, []byte(dataKey), nil))
+			if len(resp.Errs) > req.ErrLimit {
+				resp.Status = StatusFail
+				resp.Msg = "too many errors, see resp.Errs for details"
+				return resp, nil
+			}
+			continue
+		}
+		resp.Recs = append(resp.Recs, v)
+	}
+	resp.GetCnt = len(resp.Recs)
+	if len(resp.Errs) > 0 {
+		resp.Status = StatusWarning
+		resp.Msg = "see resp.Errs for details"
+	} else {
+		resp.Status = StatusOk
+	}
+	return resp, nil
+}
@@ -0,0 +1,150 @@
+package bobb
+
+/*
+UnionQryRequest runs the same QryRequest on several bkts and returns 1 result, ex. bkts partitioned by year,
+"order_2023", "order_2024", "order_2025", queried with BktPrefix "order_20".
+
+Qry Criteria, Joins, ChildJoins, Computed and Fields apply to each bkt. Qry.BktName is replaced by each bkt name.
+With SortKeys, the recs of all bkts are merged in sort order and Top limits the merged result,
+ties are in bkt order, then key order. Without SortKeys, recs are returned by bkt, then key order.
+Qry.Limit (scan limit) applies to each bkt. Qry.IndexBkt cannot be used, an index belongs to 1 bkt.
+Each rec is tagged with the name of its source bkt in SrcBktFld, before the find step,
+so the source bkt can be used in Criteria and SortKeys.
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/valyala/fastjson"
+	bolt "go.etcd.io/bbolt"
+)
+
+const DefaultSrcBktFld = "_bkt"
+
+// maxUnionBkts limits the # of bkts of a UnionQryRequest, the bkt # is part of the rec seq used to break sort ties.
+const maxUnionBkts = 1 << 14
+
+// UnionQryRequest runs Qry on each bkt of BktNames or each bkt with a name beginning with BktPrefix.
+// See comments at top of file.
+type UnionQryRequest struct {
+	Qry       QryRequest
+	BktNames  []string // bkts queried, in this order
+	BktPrefix string   // used if BktNames empty, bkts with names beginning with it, in name order, see unionBktNames for bkts skipped
+	SrcBktFld string   // fld loaded with source bkt name, default is DefaultSrcBktFld
+}
+
+func (req UnionQryRequest) IsUpdtReq() bool {
+	return false
+}
+
+func (req *UnionQryRequest) Run(tx *bolt.Tx) (*Response, error) {
+
+	resp := new(Response)
+	bktNames, err := unionBktNames(tx, req)
+	if err != nil {
+		resp.Status = StatusFail
+		resp.Msg = err.Error()
+		return resp, nil
+	}
+	qry := req.Qry
+	if qry.IndexBkt != "" {
+		resp.Status = StatusFail
+		resp.Msg = "Qry.IndexBkt cannot be used in a union qry"
+		return resp, nil
+	}
+	srcFld := req.SrcBktFld
+	if srcFld == "" {
+		srcFld = DefaultSrcBktFld
+	}
+	if len(qry.Fields) > 0 && !slices.Contains(qry.Fields, srcFld) {
+		qry.Fields = append(slices.Clone(qry.Fields), srcFld) // source bkt always returned
+	}
+	bkts := make([]*bolt.Bucket, len(bktNames))
+	for i, bktName := range bktNames {
+		if bkts[i] = openBkt(tx, resp, bktName); bkts[i] == nil {
+			return resp, nil
+		}
+	}
+
+	var stats *ExecStats // nil unless Explain
+	if qry.Explain {
+		stats = new(ExecStats)
+	}
+	t := stats.now()
+	qry.BktName = bktNames[0]
+	scan := newQryScan(tx, &qry, resp)
+	if scan == nil {
+		return resp, nil
+	}
+	scan.srcFld = srcFld
+	stats.since("validate", t)
+
+	var results []*qryScanResult
+	var arena fastjson.Arena // bkt name values
+	partitionCnt := 0
+	for i, bkt := range bkts {
+		qry.BktName = bktNames[i]
+		scan.srcVal = arena.NewString(bktNames[i])
+		bktResults, cnt := scan.exec(bkt, nil, i<<48, stats) // bkt # in seq, so sort ties are in bkt order
+		results = append(results, bktResults...)
+		partitionCnt = max(partitionCnt, cnt)
+		if bktResults[len(bktResults)-1].failed {
+			break
+		}
+	}
+	if stats != nil {
+		qry.BktName = strings.Join(bktNames, ",")
+		stats.Plan = fmt.Sprintf("union %d bkts; %s", len(bkts), scan.plan(partitionCnt))
+	}
+	scan.finish(resp, results, stats)
+	resp.NextKey = "" // NextKey of last bkt only, not usable to continue a union
+	return resp, nil
+}
+
+// unionBktNames returns req.BktNames, or the root bkts with names beginning with req.BktPrefix.
+// With BktPrefix, bkts that do not hold data recs are skipped: index_settings, saved_queries,
+// index and inverted bkts of the index settings, and put log bkts (bktname_putlog, see PutRequest LogPut).
+func unionBktNames(tx *bolt.Tx, req *UnionQryRequest) ([]string, error) {
+	bktNames := req.BktNames
+	if len(bktNames) == 0 {
+		if req.BktPrefix == "" {
+			return nil, fmt.Errorf("request missing BktNames or BktPrefix")
+		}
+		skip := map[string]bool{IndexSettingsBkt: true, SavedQueriesBkt: true}
+		if settingsBkt := tx.Bucket([]byte(IndexSettingsBkt)); settingsBkt != nil {
+			err := settingsBkt.ForEach(func(_, v []byte) error {
+				var setting IndexSetting
+				if err := json.Unmarshal(v, &setting); err != nil {
+					return err
+				}
+				skip[setting.IndexBkt] = true
+				skip[setting.IndexBkt+"_inverted"] = true
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("error loading index settings - %s", err.Error())
+			}
+		}
+		tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			bktName := string(name)
+			if strings.HasPrefix(bktName, req.BktPrefix) && !skip[bktName] {
+				bktNames = append(bktNames, bktName)
+			}
+			return nil
+		})
+		bktNames = slices.DeleteFunc(bktNames, func(bktName string) bool {
+			logged, isLog := strings.CutSuffix(bktName, "_putlog")
+			return isLog && tx.Bucket([]byte(logged)) != nil
+		})
+		if len(bktNames) == 0 {
+			return nil, fmt.Errorf("no bkts with names beginning with %s", req.BktPrefix)
+		}
+	}
+	if len(bktNames) > maxUnionBkts {
+		return nil, fmt.Errorf("union qry limited to %d bkts", maxUnionBkts)
+	}
+	return bktNames, nil
+}
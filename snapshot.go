@@ -0,0 +1,235 @@
+package bobb
+
+/*
+Snapshots give consistent reads across several requests, ex. paging through a large bkt with NextKey,
+or a report built from several queries, without seeing writes made between requests.
+
+A SnapshotRequest with Operation SnapshotOpen begins a read-only bolt trans and holds it under an Id
+(returned in Response.Rec as SnapshotInfo). GetRequest, GetAllRequest and QryRequest with Snapshot set to the Id
+run in that trans (see ViewSnapshot, called by bobb_server process) instead of a new db.View.
+The snapshot is closed by SnapshotClose or when its TTL expires. Requests using an unknown or expired Id fail.
+
+Limits, bolt cannot grow (remap) the db file while any read trans is open, so a commit that needs more space
+waits for open snapshots. While it waits, new read trans also wait, every request of the server stalls.
+To bound the stall, an update trans still running after SnapshotEvictMs closes all open snapshots (see WatchWriter),
+later requests using them fail. MaxSnapshots limits the # of open snapshots, MaxSnapshotSecs limits the TTL.
+Keep TTLs short and close snapshots when done.
+*/
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	SnapshotOpen  = "open"  // begin snapshot, Response.Rec contains SnapshotInfo
+	SnapshotClose = "close" // close snapshot Id
+	SnapshotList  = "list"  // Response.Rec contains []SnapshotInfo of open snapshots
+)
+
+const DefaultSnapshotSecs = 10 // TTL if SnapshotRequest.TTLSecs is 0
+
+var MaxSnapshots int // from bobb_settings.json, max # of open snapshots
+
+var MaxSnapshotSecs int // from bobb_settings.json, max snapshot TTL
+
+var SnapshotEvictMs int // from bobb_settings.json, update trans duration after which open snapshots are closed
+
+var ErrSnapshotNotFound = errors.New("snapshot not found, expired or evicted")
+
+// SnapshotRequest opens, closes or lists snapshots, see comments at top of file.
+// It does not run in a trans, bobb_server calls RunDB.
+type SnapshotRequest struct {
+	Operation string // SnapshotOpen, SnapshotClose or SnapshotList
+	Id        string // snapshot closed by SnapshotClose
+	TTLSecs   int    // SnapshotOpen, seconds until snapshot is closed if not closed by request, default DefaultSnapshotSecs
+}
+
+// SnapshotInfo describes an open snapshot.
+type SnapshotInfo struct {
+	Id      string
+	TxId    int // bolt trans id, the db version seen by the snapshot
+	Opened  time.Time
+	Expires time.Time
+}
+
+// SnapshotReader is implemented by requests that can run in a snapshot.
+type SnapshotReader interface {
+	SnapshotId() string // "" if request does not use a snapshot
+}
+
+// snapshot is an open read-only trans. Requests hold lock.RLock while using tx, close holds lock.Lock.
+type snapshot struct {
+	info  SnapshotInfo
+	tx    *bolt.Tx
+	lock  sync.RWMutex
+	timer *time.Timer
+}
+
+var snapshots = struct {
+	sync.Mutex
+	open map[string]*snapshot
+}{open: make(map[string]*snapshot)}
+
+func (req SnapshotRequest) IsUpdtReq() bool {
+	return false
+}
+
+// Run is not used, a snapshot trans is opened from the db, not from a request trans.
+func (req *SnapshotRequest) Run(tx *bolt.Tx) (*Response, error) {
+	return nil, fmt.Errorf("SnapshotRequest must be run with RunDB")
+}
+
+// RunDB runs the request, called by bobb_server outside of any trans.
+// Opening a read trans inside another trans of the same goroutine can deadlock with a db remap.
+func (req *SnapshotRequest) RunDB(db *bolt.DB) (*Response, error) {
+	resp := new(Response)
+	var err error
+	switch req.Operation {
+	case SnapshotOpen:
+		var info SnapshotInfo
+		if info, err = openSnapshot(db, req.TTLSecs); err != nil {
+			resp.Status = StatusFail
+			resp.Msg = err.Error()
+			return resp, nil
+		}
+		resp.Rec, err = json.Marshal(info)
+	case SnapshotClose:
+		if !closeSnapshot(req.Id) {
+			resp.Status = StatusFail
+			resp.Msg = fmt.Sprintf("%s, %s", ErrSnapshotNotFound.Error(), req.Id)
+			return resp, nil
+		}
+	case SnapshotList:
+		resp.Rec, err = json.Marshal(listSnapshots())
+	default:
+		resp.Status = StatusFail
+		resp.Msg = "Invalid Snapshot Operation-" + req.Operation
+		return resp, nil
+	}
+	if err != nil {
+		return resp, err
+	}
+	resp.Status = StatusOk
+	return resp, nil
+}
+
+// openSnapshot begins a read-only trans and registers it, it is closed after ttlSecs.
+func openSnapshot(db *bolt.DB, ttlSecs int) (SnapshotInfo, error) {
+	if ttlSecs == 0 {
+		ttlSecs = DefaultSnapshotSecs
+	}
+	if ttlSecs < 0 || ttlSecs > MaxSnapshotSecs {
+		return SnapshotInfo{}, fmt.Errorf("TTLSecs must be between 1 and %d", MaxSnapshotSecs)
+	}
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return SnapshotInfo{}, err
+	}
+
+	snapshots.Lock()
+	defer snapshots.Unlock()
+	if len(snapshots.open) >= MaxSnapshots {
+		return SnapshotInfo{}, fmt.Errorf("max # of open snapshots (%d) reached", MaxSnapshots)
+	}
+	tx, err := db.Begin(false)
+	if err != nil {
+		return SnapshotInfo{}, err
+	}
+	now := time.Now()
+	ttl := time.Duration(ttlSecs) * time.Second
+	snap := &snapshot{tx: tx, info: SnapshotInfo{Id: hex.EncodeToString(idBytes), TxId: tx.ID(), Opened: now, Expires: now.Add(ttl)}}
+	snap.timer = time.AfterFunc(ttl, func() {
+		if closeSnapshot(snap.info.Id) {
+			log.Println("snapshot expired -", snap.info.Id)
+		}
+	})
+	snapshots.open[snap.info.Id] = snap
+	Trace("snapshot opened - " + snap.info.Id)
+	return snap.info, nil
+}
+
+// closeSnapshot removes snapshot id and rolls back its trans after running requests complete.
+// Returns false if id is not open.
+func closeSnapshot(id string) bool {
+	snapshots.Lock()
+	snap := snapshots.open[id]
+	delete(snapshots.open, id)
+	snapshots.Unlock()
+	if snap == nil {
+		return false
+	}
+	snap.timer.Stop()
+	snap.lock.Lock() // wait for running requests
+	defer snap.lock.Unlock()
+	if err := snap.tx.Rollback(); err != nil {
+		log.Println("snapshot rollback failed -", id, err)
+	}
+	Trace("snapshot closed - " + id)
+	return true
+}
+
+// WatchWriter is called by bobb_server before an update trans, stop is called when the trans ends.
+// If the trans is still running after SnapshotEvictMs, open snapshots are closed,
+// the commit may be waiting to remap the db file, see comments at top of file.
+func WatchWriter() (stop func()) {
+	timer := time.AfterFunc(time.Duration(SnapshotEvictMs)*time.Millisecond, func() {
+		for _, info := range listSnapshots() {
+			if closeSnapshot(info.Id) {
+				log.Println("snapshot evicted, update trans waiting -", info.Id)
+			}
+		}
+	})
+	return func() { timer.Stop() }
+}
+
+// CloseSnapshots closes all open snapshots, called by bobb_server at shutdown.
+func CloseSnapshots() {
+	for _, info := range listSnapshots() {
+		closeSnapshot(info.Id)
+	}
+}
+
+// listSnapshots returns the open snapshots in Opened order.
+func listSnapshots() []SnapshotInfo {
+	snapshots.Lock()
+	defer snapshots.Unlock()
+	list := make([]SnapshotInfo, 0, len(snapshots.open))
+	for _, snap := range snapshots.open {
+		list = append(list, snap.info)
+	}
+	slices.SortFunc(list, func(a, b SnapshotInfo) int {
+		if n := a.Opened.Compare(b.Opened); n != 0 {
+			return n
+		}
+		return strings.Compare(a.Id, b.Id)
+	})
+	return list
+}
+
+// ViewSnapshot runs fn in the trans of snapshot id, like db.View. Returns ErrSnapshotNotFound if id is not open.
+// Requests can run concurrently in the same snapshot, as they do in a bolt read trans.
+func ViewSnapshot(id string, fn func(tx *bolt.Tx) error) error {
+	snapshots.Lock()
+	snap := snapshots.open[id]
+	snapshots.Unlock()
+	if snap == nil {
+		return ErrSnapshotNotFound
+	}
+	snap.lock.RLock()
+	defer snap.lock.RUnlock()
+	if snap.tx.DB() == nil { // closed after it was found
+		return ErrSnapshotNotFound
+	}
+	return fn(snap.tx)
+}
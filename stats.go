@@ -0,0 +1,93 @@
+package bobb
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExecStats is returned in Response.Stats when the request Explain option is true.
+// Supported by QryRequest, GetAllRequest and SearchKeysRequest.
+// For a parallel QryRequest, counts are totals of all partitions and phase durations are summed across workers,
+// except "scan" and "merge" which are elapsed times.
+type ExecStats struct {
+	Plan         string      // how the request was run, ex. bkt or index, key range, sort method
+	KeysScanned  int         // keys read from the bkt, or the index bkt if one is used
+	RecsParsed   int         // records parsed as json
+	RecsMatched  int         // records meeting Criteria (all records read if no Criteria)
+	IndexLookups int         // data bkt gets for index entries
+	JoinLookups  int         // join bkt gets
+	ErrsSkipped  int         // records skipped because of errors, see Response.Errs
+	Phases       []PhaseTime // time spent in each phase, in the order phases first ran
+}
+
+// PhaseTime is the time spent in 1 phase of a request, ex. "parse", "find", "sort".
+type PhaseTime struct {
+	Phase    string
+	Duration time.Duration
+}
+
+// now returns the current time, or zero time if stats is nil (Explain not requested).
+func (stats *ExecStats) now() time.Time {
+	if stats == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// since adds the time since start to phase. Does nothing if stats is nil.
+func (stats *ExecStats) since(phase string, start time.Time) {
+	if stats == nil {
+		return
+	}
+	stats.addPhase(phase, time.Since(start))
+}
+
+func (stats *ExecStats) addPhase(phase string, d time.Duration) {
+	for i := range stats.Phases {
+		if stats.Phases[i].Phase == phase {
+			stats.Phases[i].Duration += d
+			return
+		}
+	}
+	stats.Phases = append(stats.Phases, PhaseTime{Phase: phase, Duration: d})
+}
+
+// add adds the counts and phase times of other to stats.
+func (stats *ExecStats) add(other *ExecStats) {
+	stats.KeysScanned += other.KeysScanned
+	stats.RecsParsed += other.RecsParsed
+	stats.RecsMatched += other.RecsMatched
+	stats.IndexLookups += other.IndexLookups
+	stats.JoinLookups += other.JoinLookups
+	stats.ErrsSkipped += other.ErrsSkipped
+	for _, phase := range other.Phases {
+		stats.addPhase(phase.Phase, phase.Duration)
+	}
+}
+
+// rangePlan describes the bkt, index and key range read by a request, used for ExecStats.Plan.
+func rangePlan(bktName, indexBkt, startKey, endKey string, reverse bool) string {
+	var plan strings.Builder
+	if indexBkt != "" {
+		fmt.Fprintf(&plan, "index %s > bkt %s", indexBkt, bktName)
+	} else {
+		fmt.Fprintf(&plan, "bkt %s", bktName)
+	}
+	switch {
+	case startKey != "" && startKey == endKey:
+		fmt.Fprintf(&plan, ", key prefix %q", startKey)
+	case startKey == "" && endKey == "":
+		plan.WriteString(", all keys")
+	case endKey == "":
+		fmt.Fprintf(&plan, ", keys >= %q", startKey)
+	case startKey == "":
+		fmt.Fprintf(&plan, ", keys <= %q", endKey)
+	default:
+		fmt.Fprintf(&plan, ", keys %q to %q", startKey, endKey)
+	}
+	if reverse {
+		plan.WriteString(", reverse")
+	}
+	return plan.String()
+}
@@ -0,0 +1,151 @@
+package bobb
+
+/*
+SubQry is used by a FindCondition to get its value set from a query on another bkt (semi-join / anti-join).
+Ex. locations having at least 1 open request:
+
+	FindCondition{Fld: "id", Op: FindInStrList, SubQry: &SubQry{
+		Qry: QryRequest{BktName: "request", Criteria: ...open...},
+		Fld: "locationId"}}
+
+  - FindInStrList / FindInIntList, rec Fld value is in the set of subquery Fld values (Not for not in)
+  - FindExists, condition is met if the subquery returns any recs (Not for not exists), rec Fld is not used
+
+Each subquery runs once per request, before the read loop, in the same View trans (see resolveSubQrys).
+A subquery that fails, fails the request. Recs skipped by a subquery with StatusWarning (under its ErrLimit)
+are added to the request Response.Errs, msg begins with "SubQry on <bkt>", they do not count toward the request ErrLimit.
+String values are converted with the condition StrOption, as StrList values are. Array values add each element.
+*/
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/valyala/fastjson"
+	bolt "go.etcd.io/bbolt"
+)
+
+// SubQry defines a subquery, see comments at top of file.
+type SubQry struct {
+	Qry QryRequest // its SortKeys, Top, Fields and CountOnly are not used
+	Fld string     // fld of subquery recs giving the value set, nested flds use ".", default is DefaultKeyFld
+}
+
+// validateSubQry checks condition op and values can be used with condition.SubQry.
+func validateSubQry(condition FindCondition) error {
+	switch condition.Op {
+	case FindInStrList, FindInIntList, FindExists:
+	default:
+		return fmt.Errorf("SubQry cannot be used with op %s", condition.Op)
+	}
+	if len(condition.StrList) > 0 || len(condition.IntList) > 0 {
+		return fmt.Errorf("SubQry and StrList/IntList cannot both be used, fld %s", condition.Fld)
+	}
+	if condition.SubQry.Qry.BktName == "" {
+		return fmt.Errorf("SubQry missing Qry.BktName, fld %s", condition.Fld)
+	}
+	return nil
+}
+
+// resolveSubQrys runs the subqueries of criteria and returns criteria with subquery value sets loaded.
+// criteria is not changed (it may be cached), conditions with a SubQry are copied.
+// Errs of subqueries with StatusWarning are appended to subErrs, added to Response.Errs when the request ends.
+func resolveSubQrys(tx *bolt.Tx, criteria []FindGroup, subErrs *[]BobbErr) ([]FindGroup, error) {
+	var resolved []FindGroup // copy made at 1st SubQry
+	for i, group := range criteria {
+		for j, condition := range group {
+			if condition.SubQry == nil {
+				continue
+			}
+			if resolved == nil {
+				resolved = make([]FindGroup, len(criteria))
+				for k := range criteria {
+					resolved[k] = slices.Clone(criteria[k])
+				}
+			}
+			if err := resolved[i][j].runSubQry(tx, subErrs); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if resolved == nil {
+		return criteria, nil
+	}
+	return resolved, nil
+}
+
+// runSubQry runs condition.SubQry and loads the value set (strSet or intSet) or subExists.
+// Errs of a subquery with StatusWarning are appended to subErrs, StatusFail is returned as error.
+func (condition *FindCondition) runSubQry(tx *bolt.Tx, subErrs *[]BobbErr) error {
+	sub := condition.SubQry.Qry
+	sub.SortKeys, sub.Top, sub.CountOnly, sub.Explain, sub.Parallel = nil, 0, false, false, 0
+	fld := condition.SubQry.Fld
+	if fld == "" {
+		fld = DefaultKeyFld
+	}
+	if condition.Op == FindExists {
+		sub.Limit, sub.Fields = 1, []string{fld}
+	} else {
+		sub.Fields = []string{"v=" + fld}
+	}
+	resp, err := sub.Run(tx)
+	if err == nil && resp.Status != StatusOk && resp.Status != StatusWarning {
+		msg := resp.Msg
+		if len(resp.Errs) > 0 {
+			msg += ", 1st err: " + resp.Errs[0].Msg
+		}
+		err = fmt.Errorf("%s", msg)
+	}
+	if err != nil {
+		return fmt.Errorf("SubQry on %s failed - %s", sub.BktName, err.Error())
+	}
+	for _, bErr := range resp.Errs { // recs skipped by subquery
+		bErr.Msg = fmt.Sprintf("SubQry on %s - %s", sub.BktName, bErr.Msg)
+		*subErrs = append(*subErrs, bErr)
+	}
+	if condition.Op == FindExists {
+		condition.subExists = resp.GetCnt > 0
+		return nil
+	}
+
+	parser := parserPool.Get()
+	defer parserPool.Put(parser)
+	if condition.Op == FindInStrList {
+		condition.strSet = make(map[string]bool, len(resp.Recs))
+	} else {
+		condition.intSet = make(map[int]bool, len(resp.Recs))
+	}
+	var add func(val *fastjson.Value)
+	add = func(val *fastjson.Value) {
+		switch {
+		case val == nil || val.Type() == fastjson.TypeNull:
+		case val.Type() == fastjson.TypeArray:
+			for _, item := range val.GetArray() {
+				add(item)
+			}
+		case condition.strSet != nil:
+			s := string(val.GetStringBytes())
+			if val.Type() != fastjson.TypeString {
+				s = val.String() // number or bool as json text
+			}
+			switch condition.StrOption {
+			case StrLowerCase:
+				s = strings.ToLower(s)
+			case StrPlain:
+				s = PlainString(s)
+			}
+			condition.strSet[s] = true
+		case val.Type() == fastjson.TypeNumber:
+			condition.intSet[val.GetInt()] = true
+		}
+	}
+	for _, rec := range resp.Recs {
+		parsedRec, err := parser.ParseBytes(rec)
+		if err != nil {
+			return fmt.Errorf("SubQry on %s, error parsing result - %s", sub.BktName, err.Error())
+		}
+		add(parsedRec.Get("v"))
+	}
+	return nil
+}
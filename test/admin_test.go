@@ -0,0 +1,145 @@
+package test
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/jayposs/bobb"
+	bo "github.com/jayposs/bobb/client"
+	data "github.com/jayposs/bobb/datatypes"
+)
+
+const (
+	adminTestBkt   = "admin_test"
+	adminCopyBkt   = "admin_test_copy"
+	adminRenameBkt = "admin_test_renamed"
+	adminCityIndex = "admin_test_city_index"
+)
+
+// TestBktAdmin covers BktAdminRequest copy, rename, drop index and drop bkt operations.
+// Verifies index bkts, inverted bkts and index_settings entries follow the data bkt.
+func TestBktAdmin(t *testing.T) {
+	bo.BaseURL = "http://localhost:50555/"
+	bo.Debug = false
+
+	httpClient := &http.Client{}
+
+	cleanup := func() {
+		for _, bkt := range []string{adminTestBkt, adminCopyBkt, adminRenameBkt} {
+			bo.Run(httpClient, bobb.OpBktAdmin, bobb.BktAdminRequest{BktName: bkt, Operation: bobb.AdminDropBkt})
+		}
+	}
+	cleanup()
+	defer cleanup()
+
+	setting := bobb.IndexSetting{
+		DataBkt:  adminTestBkt,
+		IndexBkt: adminCityIndex,
+		KeyFlds: []bobb.FldFormat{
+			{FldName: "city", FldType: bobb.FldTypeStr, Length: 20, StrOption: bobb.StrLowerCase, UseDefault: bobb.DefaultAlways},
+		},
+	}
+	resp, err := bo.Run(httpClient, bobb.OpIndexSetting, bobb.IndexSettingRequest{IndexSettings: []bobb.IndexSetting{setting}})
+	if err := checkResp(resp, err, "BktAdmin - IndexSettingRequest"); err != nil {
+		t.Fatal(err)
+	}
+	testRecs := []data.Location{
+		{Id: "a1", City: "Memphis", St: "TN"},
+		{Id: "a2", City: "Austin", St: "TX"},
+		{Id: "a3", City: "Portland", St: "OR"},
+	}
+	resp, err = bo.Put(httpClient, adminTestBkt, bo.SliceToJson(testRecs), nil)
+	if err := checkResp(resp, err, "BktAdmin - Put"); err != nil {
+		t.Fatal(err)
+	}
+
+	// copy - new data bkt, index bkt and inverted bkt created, original untouched
+	resp, err = bo.Run(httpClient, bobb.OpBktAdmin, bobb.BktAdminRequest{BktName: adminTestBkt, NewBktName: adminCopyBkt, Operation: bobb.AdminCopyBkt})
+	if err := checkResp(resp, err, "BktAdmin - copy"); err != nil {
+		t.Fatal(err)
+	}
+	bkts := bo.GetBktList(httpClient)
+	for _, bkt := range []string{adminTestBkt, adminCityIndex, adminCopyBkt, "admin_test_copy_city_index", "admin_test_copy_city_index_inverted"} {
+		if !slices.Contains(bkts, bkt) {
+			t.Errorf("BktAdmin copy: bkt %s not found", bkt)
+		}
+	}
+	// put to copied bkt uses copied index setting
+	resp, err = bo.Put(httpClient, adminCopyBkt, bo.SliceToJson([]data.Location{{Id: "a4", City: "Boston", St: "MA"}}), nil)
+	if err := checkResp(resp, err, "BktAdmin - Put to copy"); err != nil {
+		t.Fatal(err)
+	}
+	if n := bo.GetRecCount(httpClient, "admin_test_copy_city_index"); n != 4 {
+		t.Errorf("BktAdmin copy: expected 4 index entries, got %d", n)
+	}
+	if n := bo.GetRecCount(httpClient, adminCityIndex); n != 3 {
+		t.Errorf("BktAdmin copy: expected original index unchanged with 3 entries, got %d", n)
+	}
+
+	// rename - old bkts removed, new ones verify ok
+	resp, err = bo.Run(httpClient, bobb.OpBktAdmin, bobb.BktAdminRequest{BktName: adminTestBkt, NewBktName: adminRenameBkt, Operation: bobb.AdminRenameBkt})
+	if err := checkResp(resp, err, "BktAdmin - rename"); err != nil {
+		t.Fatal(err)
+	}
+	bkts = bo.GetBktList(httpClient)
+	if slices.Contains(bkts, adminTestBkt) || slices.Contains(bkts, adminCityIndex) || slices.Contains(bkts, adminCityIndex+"_inverted") {
+		t.Errorf("BktAdmin rename: old bkts still exist")
+	}
+	resp, err = bo.Run(httpClient, bobb.OpVerifyIndex, bobb.VerifyIndexRequest{
+		DataBkt:        adminRenameBkt,
+		IndexBkt:       "admin_test_renamed_city_index",
+		AllDataIndexed: true,
+		ErrLimit:       10,
+	})
+	if err := checkResp(resp, err, "BktAdmin - verify renamed index"); err != nil {
+		t.Error(err)
+	}
+
+	// drop index - setting removed, so put no longer recreates index bkt
+	resp, err = bo.Run(httpClient, bobb.OpBktAdmin, bobb.BktAdminRequest{IndexBkt: "admin_test_renamed_city_index", Operation: bobb.AdminDropIndex})
+	if err := checkResp(resp, err, "BktAdmin - drop index"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = bo.Put(httpClient, adminRenameBkt, bo.SliceToJson([]data.Location{{Id: "a5", City: "Denver", St: "CO"}}), nil)
+	if err := checkResp(resp, err, "BktAdmin - Put after drop index"); err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(bo.GetBktList(httpClient), "admin_test_renamed_city_index") {
+		t.Error("BktAdmin drop index: index bkt recreated by Put")
+	}
+
+	// dropping index again is skipped and reported in resp.Errs
+	resp, _ = bo.Run(httpClient, bobb.OpBktAdmin, bobb.BktAdminRequest{IndexBkt: "admin_test_renamed_city_index", Operation: bobb.AdminDropIndex})
+	if resp.Status != bobb.StatusWarning || len(resp.Errs) == 0 {
+		t.Errorf("BktAdmin drop missing index: expected StatusWarning with Errs, got %s, %d errs", resp.Status, len(resp.Errs))
+	}
+
+	// delete does not create index bkts of a setting that has none yet
+	setting = bobb.IndexSetting{DataBkt: adminRenameBkt, IndexBkt: "admin_test_renamed_st_index",
+		KeyFlds: []bobb.FldFormat{{FldName: "st", FldType: bobb.FldTypeStr, Length: 2, StrOption: bobb.StrAsIs, UseDefault: bobb.DefaultAlways}}}
+	resp, err = bo.Run(httpClient, bobb.OpIndexSetting, bobb.IndexSettingRequest{IndexSettings: []bobb.IndexSetting{setting}})
+	if err := checkResp(resp, err, "BktAdmin - IndexSettingRequest st"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = bo.Run(httpClient, bobb.OpDelete, bobb.DeleteRequest{BktName: adminRenameBkt, Keys: []string{"a5"}})
+	if err := checkResp(resp, err, "BktAdmin - Delete"); err != nil {
+		t.Fatal(err)
+	}
+	bkts = bo.GetBktList(httpClient)
+	if slices.Contains(bkts, "admin_test_renamed_st_index") || slices.Contains(bkts, "admin_test_renamed_st_index_inverted") {
+		t.Error("BktAdmin delete: index bkts created by Delete")
+	}
+
+	// drop bkt with dependents
+	resp, err = bo.Run(httpClient, bobb.OpBktAdmin, bobb.BktAdminRequest{BktName: adminCopyBkt, Operation: bobb.AdminDropBkt})
+	if err := checkResp(resp, err, "BktAdmin - drop bkt"); err != nil {
+		t.Fatal(err)
+	}
+	bkts = bo.GetBktList(httpClient)
+	for _, bkt := range []string{adminCopyBkt, "admin_test_copy_city_index", "admin_test_copy_city_index_inverted"} {
+		if slices.Contains(bkts, bkt) {
+			t.Errorf("BktAdmin drop bkt: bkt %s still exists", bkt)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/jayposs/bobb"
@@ -122,3 +124,169 @@ func TestIndexing1(t *testing.T) {
 
 	log.Println("-- testIndexSetting done -----")
 }
+
+// -- TestIndexingDesc -------------------------------------------
+// Tests FldFormat Desc, Transform and negative int encoding.
+// Index is (st asc, lastActionDt month desc, Int1 asc), so forward cursor returns latest month first within st.
+func TestIndexingDesc(t *testing.T) {
+	bo.BaseURL = "http://localhost:50555/"
+	bo.Debug = false
+
+	httpClient := &http.Client{}
+
+	const testBkt = "index_desc_test"
+	const testIndex = "index_desc_test_st_month_index"
+
+	bo.DeleteBkt(httpClient, testBkt)
+	bo.DeleteBkt(httpClient, testIndex)
+	bo.DeleteBkt(httpClient, testIndex+"_inverted")
+
+	setting := bobb.IndexSetting{
+		DataBkt:      testBkt,
+		IndexBkt:     testIndex,
+		FldSeparator: "|",
+		KeyFlds: []bobb.FldFormat{
+			{FldName: "st", FldType: bobb.FldTypeStr, Length: 2, StrOption: bobb.StrLowerCase, UseDefault: bobb.DefaultAlways},
+			{FldName: "lastActionDt", FldType: bobb.FldTypeStr, Length: 7, StrOption: bobb.StrAsIs, UseDefault: bobb.DefaultAlways, Transform: bobb.TransformMonth, Desc: true},
+			{FldName: "Int1", FldType: bobb.FldTypeInt, Length: 5, UseDefault: bobb.DefaultAlways},
+		},
+	}
+	resp, err := bo.Run(httpClient, bobb.OpIndexSetting, bobb.IndexSettingRequest{IndexSettings: []bobb.IndexSetting{setting}})
+	if err := checkResp(resp, err, "TestIndexingDesc - IndexSettingRequest"); err != nil {
+		t.Fatal(err)
+	}
+	testRecs := []data.Location{
+		{Id: "d1", St: "TN", LastActionDt: "2024-01-15", Int1: 5},
+		{Id: "d2", St: "TN", LastActionDt: "2024-03-02", Int1: -20},
+		{Id: "d3", St: "TN", LastActionDt: "2024-03-28", Int1: -3},
+		{Id: "d4", St: "TN", LastActionDt: "2023-12-31", Int1: 0},
+		{Id: "d5", St: "AL", LastActionDt: "2022-06-01", Int1: 1},
+	}
+	resp, err = bo.Put(httpClient, testBkt, bo.SliceToJson(testRecs), nil)
+	if err := checkResp(resp, err, "TestIndexingDesc - Put"); err != nil {
+		t.Fatal(err)
+	}
+
+	// st asc, month desc, Int1 asc (negatives first): d5, d2, d3, d1, d4
+	resp, err = bo.Run(httpClient, bobb.OpGetAll, bobb.GetAllRequest{BktName: testBkt, IndexBkt: testIndex})
+	if err := checkResp(resp, err, "TestIndexingDesc - GetAll via index"); err != nil {
+		t.Fatal(err)
+	}
+	results := bo.JsonToSlice(resp.Recs, data.Location{})
+	expectedOrder := []string{"d5", "d2", "d3", "d1", "d4"}
+	for i, expectedId := range expectedOrder {
+		if i >= len(results) || results[i].Id != expectedId {
+			t.Fatalf("TestIndexingDesc - GetAll order wrong at pos %d, expected %s", i, expectedId)
+		}
+	}
+
+	// GetByIndex, tn and month range given in asc order on desc fld: 2024-01 to 2024-03 → d2, d3, d1
+	resp, err = bo.Run(httpClient, bobb.OpGetByIndex, bobb.GetByIndexRequest{
+		IndexBkt: testIndex,
+		Conditions: []bobb.IndexCondition{
+			{ValStr: "TN"},
+			{Op: bobb.IndexRange, ValStr: "2024-01-01", ToStr: "2024-03-31"},
+		},
+	})
+	if err := checkResp(resp, err, "TestIndexingDesc - GetByIndex range"); err != nil {
+		t.Fatal(err)
+	}
+	results = bo.JsonToSlice(resp.Recs, data.Location{})
+	if len(results) != 3 || results[0].Id != "d2" || results[1].Id != "d3" || results[2].Id != "d1" {
+		t.Errorf("TestIndexingDesc - GetByIndex range, expected d2, d3, d1, got %d recs", len(results))
+	}
+
+	// GetByIndex, prefix on desc fld: tn, "2023" → d4
+	resp, err = bo.Run(httpClient, bobb.OpGetByIndex, bobb.GetByIndexRequest{
+		IndexBkt: testIndex,
+		Conditions: []bobb.IndexCondition{
+			{ValStr: "TN"},
+			{Op: bobb.IndexPrefix, ValStr: "2023"},
+		},
+	})
+	if err := checkResp(resp, err, "TestIndexingDesc - GetByIndex prefix"); err != nil {
+		t.Fatal(err)
+	}
+	results = bo.JsonToSlice(resp.Recs, data.Location{})
+	if len(results) != 1 || results[0].Id != "d4" {
+		t.Errorf("TestIndexingDesc - GetByIndex prefix, expected d4, got %d recs", len(results))
+	}
+
+	// IndexRequest MergeFlds validated like IndexSetting KeyFlds, TransformPrefix without PrefixLen → StatusFail
+	resp, _ = bo.Run(httpClient, bobb.OpIndexRequest, bobb.IndexRequest{
+		DataBkt:   testBkt,
+		IndexBkt:  testBkt + "_city_index",
+		MergeFlds: []bobb.FldFormat{{FldName: "city", FldType: bobb.FldTypeStr, Length: 4, StrOption: bobb.StrAsIs, UseDefault: bobb.DefaultAlways, Transform: bobb.TransformPrefix}},
+		IndexAll:  true,
+	})
+	if resp.Status != bobb.StatusFail {
+		t.Errorf("TestIndexingDesc - IndexRequest TransformPrefix without PrefixLen, expected StatusFail, got %s", resp.Status)
+	}
+
+	// IndexRequest MergeFlds with StrOption and UseDefault omitted → StrLowerCase, DefaultAlways
+	stIndex := testBkt + "_st_index"
+	bo.DeleteBkt(httpClient, stIndex)
+	defer bo.DeleteBkt(httpClient, stIndex)
+	resp, err = bo.Run(httpClient, bobb.OpIndexRequest, bobb.IndexRequest{
+		DataBkt:   testBkt,
+		IndexBkt:  stIndex,
+		MergeFlds: []bobb.FldFormat{{FldName: "st", FldType: bobb.FldTypeStr, Length: 2}},
+		IndexAll:  true,
+	})
+	if err := checkResp(resp, err, "TestIndexingDesc - IndexRequest default StrOption"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = bo.Run(httpClient, bobb.OpGetAllKeys, bobb.GetAllKeysRequest{BktName: stIndex})
+	if err := checkResp(resp, err, "TestIndexingDesc - GetAllKeys default StrOption"); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Recs) != 5 || !strings.HasPrefix(string(resp.Recs[0]), "al") {
+		t.Errorf("TestIndexingDesc - IndexRequest default StrOption, expected 5 lower case keys, got %q", resp.Recs)
+	}
+
+	// IndexStats: 5 keys, st component has 2 distinct values, tn most frequent, 2 partitions of 3 and 2 keys
+	resp, err = bo.Run(httpClient, bobb.OpIndexStats, bobb.IndexStatsRequest{BktName: testIndex, Partitions: 2})
+	if err := checkResp(resp, err, "TestIndexingDesc - IndexStats"); err != nil {
+		t.Fatal(err)
+	}
+	var stats bobb.IndexStats
+	if err := json.Unmarshal(resp.Rec, &stats); err != nil {
+		t.Fatal("TestIndexingDesc - IndexStats unmarshal error", err)
+	}
+	if stats.KeyCount != 5 || len(stats.Components) != 3 {
+		t.Errorf("TestIndexingDesc - IndexStats expected 5 keys and 3 components, got %d, %d", stats.KeyCount, len(stats.Components))
+	} else {
+		st := stats.Components[0]
+		if st.Name != "st" || st.DistinctPrefixes != 2 || st.TopValues[0].Value != "tn" || st.TopValues[0].Count != 4 {
+			t.Errorf("TestIndexingDesc - IndexStats st component wrong: %+v", st)
+		}
+		if stats.Components[1].DistinctPrefixes != 4 { // al|2022-06, tn|2024-03, tn|2024-01, tn|2023-12
+			t.Errorf("TestIndexingDesc - IndexStats month component expected 4 distinct prefixes, got %d", stats.Components[1].DistinctPrefixes)
+		}
+		if month := stats.Components[1]; month.TopValues[0].Value != "2024-03" || month.TopValues[0].Count != 2 || month.Approx {
+			t.Errorf("TestIndexingDesc - IndexStats month component expected decoded top value 2024-03, got %+v", month)
+		}
+	}
+	if len(stats.Partitions) != 2 || stats.Partitions[0].Count != 3 || stats.Partitions[1].Count != 2 {
+		t.Errorf("TestIndexingDesc - IndexStats expected partitions of 3 and 2 keys, got %+v", stats.Partitions)
+	}
+
+	// 5 keys in 4 partitions, remainder spread: 2, 1, 1, 1
+	resp, err = bo.Run(httpClient, bobb.OpIndexStats, bobb.IndexStatsRequest{BktName: testIndex, Partitions: 4})
+	if err := checkResp(resp, err, "TestIndexingDesc - IndexStats 4 partitions"); err != nil {
+		t.Fatal(err)
+	}
+	stats = bobb.IndexStats{}
+	json.Unmarshal(resp.Rec, &stats)
+	partitionCounts := make([]int, len(stats.Partitions))
+	for i, partition := range stats.Partitions {
+		partitionCounts[i] = partition.Count
+	}
+	if !slices.Equal(partitionCounts, []int{2, 1, 1, 1}) || len(stats.SplitKeys) != 4 {
+		t.Errorf("TestIndexingDesc - IndexStats expected partitions of 2, 1, 1, 1 keys, got %+v", stats.Partitions)
+	}
+
+	bo.DeleteBkt(httpClient, testBkt)
+	bo.DeleteBkt(httpClient, testIndex)
+	bo.DeleteBkt(httpClient, testIndex+"_inverted")
+}
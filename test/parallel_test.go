@@ -0,0 +1,123 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jayposs/bobb"
+	bo "github.com/jayposs/bobb/client"
+	data "github.com/jayposs/bobb/datatypes"
+)
+
+const parallelTestBkt = "parallel_test"
+
+// TestQryParallel verifies QryRequest results do not depend on Parallel (# of workers).
+// Each request is run sequentially, then with several Parallel values, and responses compared.
+func TestQryParallel(t *testing.T) {
+	bo.BaseURL = "http://localhost:50555/"
+	bo.Debug = false
+
+	httpClient := &http.Client{}
+
+	bo.DeleteBkt(httpClient, parallelTestBkt)
+	defer bo.DeleteBkt(httpClient, parallelTestBkt)
+
+	cities := []string{"Austin", "Boston", "Chicago", "Denver", "Houston", "Memphis", "Portland"}
+	recs := make([]data.Location, 2000)
+	for i := range recs {
+		recs[i] = data.Location{
+			Id:           fmt.Sprintf("p%05d", i),
+			City:         cities[i%len(cities)],
+			LocationType: i % 5,
+			Int1:         (i * 7919) % 1000, // many ties and out of key order values
+		}
+		if i%3 == 0 {
+			recs[i].NullTest = &qryNonNullStr // other recs are errors with DefaultNever
+		}
+	}
+	resp, err := bo.Put(httpClient, parallelTestBkt, bo.SliceToJson(recs), nil)
+	if err := checkResp(resp, err, "QryParallel - Put"); err != nil {
+		t.Fatal(err)
+	}
+
+	testReqs := map[string]bobb.QryRequest{
+		"key order": {
+			Criteria: []bobb.FindGroup{bo.Find(nil, "city", bobb.FindMatches, "Denver")},
+		},
+		"range": {
+			StartKey: "p00100",
+			EndKey:   "p01500",
+			Criteria: []bobb.FindGroup{bo.Find(nil, "locationType", bobb.FindEquals, 3)},
+		},
+		"prefix": {
+			StartKey: "p01",
+			EndKey:   "p01",
+		},
+		"sort": {
+			SortKeys: []bobb.SortKey{{Fld: "Int1", Dir: bobb.SortDescInt}, {Fld: "city", Dir: bobb.SortAscStr}},
+		},
+		"sort top": {
+			SortKeys: []bobb.SortKey{{Fld: "locationType", Dir: bobb.SortAscInt}},
+			Top:      150,
+		},
+		"computed and fields": {
+			Computed: []bobb.ComputedFld{{Fld: "x", Expr: "Int1 * 2 + len(city)"}},
+			SortKeys: []bobb.SortKey{{Fld: "x", Dir: bobb.SortAscInt}},
+			Fields:   []string{"id", "x"},
+			Top:      500,
+		},
+		"errors": {
+			Criteria: []bobb.FindGroup{{bobb.FindCondition{Fld: "nulltest", Op: bobb.FindMatches, ValStr: "set", UseDefault: bobb.DefaultNever}}},
+			ErrLimit: -1,
+		},
+		"error limit": {
+			Criteria: []bobb.FindGroup{{bobb.FindCondition{Fld: "nulltest", Op: bobb.FindMatches, ValStr: "set", UseDefault: bobb.DefaultNever}}},
+			ErrLimit: 5,
+		},
+	}
+	// partitions found by seeking, about partitionsPerWorker (4) per worker
+	for _, req := range []bobb.QryRequest{{}, {StartKey: "p01", EndKey: "p01"}} {
+		req.BktName, req.Parallel, req.Explain = parallelTestBkt, 2, true
+		resp, err := bo.Run(httpClient, bobb.OpQry, req)
+		if err := checkResp(resp, err, "QryParallel - Explain"); err != nil {
+			t.Fatal(err)
+		}
+		var partitionCnt, workers int
+		_, plan, _ := strings.Cut(resp.Stats.Plan, "; parallel ")
+		fmt.Sscanf(plan, "%d partitions, %d workers", &partitionCnt, &workers)
+		if partitionCnt < 4 || workers != 2 {
+			t.Errorf("QryParallel %s-%s: expected at least 4 partitions and 2 workers, got plan %q", req.StartKey, req.EndKey, resp.Stats.Plan)
+		}
+	}
+
+	for name, req := range testReqs {
+		req.BktName = parallelTestBkt
+		expected, err := bo.Run(httpClient, bobb.OpQry, req)
+		if err != nil {
+			t.Fatalf("%s: sequential run error %s", name, err)
+		}
+		if expected.GetCnt == 0 && expected.Status != bobb.StatusFail {
+			t.Errorf("%s: sequential run returned no recs", name)
+		}
+		for _, parallel := range []int{2, 3, 8} {
+			req.Parallel = parallel
+			resp, err := bo.Run(httpClient, bobb.OpQry, req)
+			if err != nil {
+				t.Fatalf("%s parallel %d: run error %s", name, parallel, err)
+			}
+			if resp.Status != expected.Status || resp.GetCnt != expected.GetCnt || resp.NextKey != expected.NextKey {
+				t.Errorf("%s parallel %d: expected status %s, cnt %d, next key %q, got %s, %d, %q",
+					name, parallel, expected.Status, expected.GetCnt, expected.NextKey, resp.Status, resp.GetCnt, resp.NextKey)
+			}
+			if !slices.EqualFunc(resp.Recs, expected.Recs, slices.Equal) {
+				t.Errorf("%s parallel %d: recs differ from sequential run", name, parallel)
+			}
+			if len(resp.Errs) != len(expected.Errs) || (len(resp.Errs) > 0 && string(resp.Errs[len(resp.Errs)-1].Key) != string(expected.Errs[len(expected.Errs)-1].Key)) {
+				t.Errorf("%s parallel %d: expected %d errs, got %d", name, parallel, len(expected.Errs), len(resp.Errs))
+			}
+		}
+	}
+}
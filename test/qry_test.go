@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"slices"
+	"strings"
 	"testing"
 
 	"github.com/jayposs/bobb"
@@ -231,6 +232,78 @@ func TestQry(t *testing.T) {
 		}
 	})
 
+	// -----------------------------------------------------------------------
+	t.Run("FindRegexFuzzy", func(t *testing.T) {
+		// FindRegex: default StrLowerCase is case insensitive, "^(AUS|hou)" → Austin (001,005), Houston (008) = 3
+		resp, err := bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{
+			BktName: qryTestBkt,
+			Criteria: []bobb.FindGroup{
+				bo.Find(nil, "city", bobb.FindRegex, "^(AUS|hou)"),
+			},
+		})
+		if err := checkResp_qry_test(resp, err, "FindRegex"); err != nil {
+			t.Error(err)
+		}
+		if resp.GetCnt != 3 {
+			t.Errorf("FindRegex: expected 3, got %d", resp.GetCnt)
+		}
+
+		// FindRegex StrAsIs: case sensitive, "^a" matches nothing
+		resp, err = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{
+			BktName: qryTestBkt,
+			Criteria: []bobb.FindGroup{
+				{bobb.FindCondition{Fld: "city", Op: bobb.FindRegex, ValStr: "^a", StrOption: bobb.StrAsIs}},
+			},
+		})
+		if err := checkResp_qry_test(resp, err, "FindRegex StrAsIs"); err != nil {
+			t.Error(err)
+		}
+		if resp.GetCnt != 0 {
+			t.Errorf("FindRegex StrAsIs: expected 0, got %d", resp.GetCnt)
+		}
+
+		// invalid regex → validation failure
+		resp, _ = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{
+			BktName: qryTestBkt,
+			Criteria: []bobb.FindGroup{
+				bo.Find(nil, "city", bobb.FindRegex, "(["),
+			},
+		})
+		if resp.Status != bobb.StatusFail {
+			t.Errorf("FindRegex invalid: expected StatusFail, got %s", resp.Status)
+		}
+
+		// FindFuzzy: default MaxDist 1, "Bostn" → Boston (002)
+		resp, err = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{
+			BktName: qryTestBkt,
+			Criteria: []bobb.FindGroup{
+				bo.Find(nil, "city", bobb.FindFuzzy, "Bostn"),
+			},
+		})
+		if err := checkResp_qry_test(resp, err, "FindFuzzy"); err != nil {
+			t.Error(err)
+		}
+		results := bo.JsonToSlice(resp.Recs, data.Location{})
+		if got := ids(results); !slices.Equal(got, []string{"002"}) {
+			t.Errorf("FindFuzzy: expected [002], got %v", got)
+		}
+
+		// FindFuzzy MaxDist 2: "hustin" → Austin (001, 005) 1 edit, Houston (008) 2 edits
+		resp, err = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{
+			BktName: qryTestBkt,
+			Criteria: []bobb.FindGroup{
+				{bobb.FindCondition{Fld: "city", Op: bobb.FindFuzzy, ValStr: "hustin", MaxDist: 2}},
+			},
+		})
+		if err := checkResp_qry_test(resp, err, "FindFuzzy MaxDist"); err != nil {
+			t.Error(err)
+		}
+		results = bo.JsonToSlice(resp.Recs, data.Location{})
+		if got := ids(results); !slices.Equal(got, []string{"001", "005", "008"}) {
+			t.Errorf("FindFuzzy MaxDist: expected [001 005 008], got %v", got)
+		}
+	})
+
 	// -----------------------------------------------------------------------
 	t.Run("FindInt", func(t *testing.T) {
 		// FindEquals: locationType == 1 → 001, 003, 006, 009 = 4
@@ -574,6 +647,33 @@ func TestQry(t *testing.T) {
 		if results[4].City != "Denver" {
 			t.Errorf("Limit+Sort: expected last city Denver, got %s", results[4].City)
 		}
+
+		// Top uses a bounded heap, results must match the first Top recs of the full sort.
+		// Desc str and int keys, and a negative float expression key, ties in key order.
+		sortKeys := []bobb.SortKey{
+			{Fld: "locationType", Dir: bobb.SortDescInt},
+			{Fld: "st", Dir: bobb.SortDescStr},
+			{Expr: "0 - len(city) * 1.5", Dir: bobb.SortAscInt},
+		}
+		resp, err = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{BktName: qryTestBkt, SortKeys: sortKeys})
+		if err := checkResp_qry_test(resp, err, "Top heap - full sort"); err != nil {
+			t.Fatal(err)
+		}
+		fullSort := ids(bo.JsonToSlice(resp.Recs, data.Location{}))
+		expected := []string{"007", "010", "004", "008", "005", "002", "001", "009", "003", "006"}
+		if !slices.Equal(fullSort, expected) {
+			t.Errorf("Top heap - full sort: expected %v, got %v", expected, fullSort)
+		}
+		for top := 1; top <= len(qryLocs)+1; top++ {
+			resp, err = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{BktName: qryTestBkt, SortKeys: sortKeys, Top: top})
+			if err := checkResp_qry_test(resp, err, "Top heap"); err != nil {
+				t.Fatal(err)
+			}
+			got := ids(bo.JsonToSlice(resp.Recs, data.Location{}))
+			if !slices.Equal(got, fullSort[:min(top, len(fullSort))]) {
+				t.Errorf("Top heap %d: expected %v, got %v", top, fullSort[:min(top, len(fullSort))], got)
+			}
+		}
 	})
 
 	// -----------------------------------------------------------------------
@@ -610,6 +710,667 @@ func TestQry(t *testing.T) {
 		}
 	})
 
+	// -----------------------------------------------------------------------
+	t.Run("Expressions", func(t *testing.T) {
+		// Expr in place of Fld: len(city) > 7 → Flagstaff, Green Bay, Indianapolis, Jacksonville
+		resp, err := bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{
+			BktName: qryTestBkt,
+			Criteria: []bobb.FindGroup{
+				{bobb.FindCondition{Expr: "len(city)", Op: bobb.FindGreaterThan, ValInt: 7}},
+			},
+		})
+		if err := checkResp_qry_test(resp, err, "Expr len"); err != nil {
+			t.Error(err)
+		}
+		results := bo.JsonToSlice(resp.Recs, data.Location{})
+		if got := ids(results); !slices.Equal(got, []string{"006", "007", "009", "010"}) {
+			t.Errorf("Expr len: expected [006 007 009 010], got %v", got)
+		}
+
+		// ValExpr, fld to fld: zip starts with locationType → 010 (32201, type 3)
+		resp, err = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{
+			BktName: qryTestBkt,
+			Criteria: []bobb.FindGroup{
+				{bobb.FindCondition{Fld: "zip", Op: bobb.FindStartsWith, ValExpr: "locationType"}},
+			},
+		})
+		if err := checkResp_qry_test(resp, err, "ValExpr"); err != nil {
+			t.Error(err)
+		}
+		results = bo.JsonToSlice(resp.Recs, data.Location{})
+		if got := ids(results); !slices.Equal(got, []string{"010"}) {
+			t.Errorf("ValExpr: expected [010], got %v", got)
+		}
+
+		// date parse: lastActionDt after 2024-01-01 (unix 1704067200) → 005, 009
+		resp, err = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{
+			BktName: qryTestBkt,
+			Criteria: []bobb.FindGroup{
+				{bobb.FindCondition{Expr: "date(lastActionDt)", Op: bobb.FindGreaterThan, ValInt: 1704067200}},
+			},
+		})
+		if err := checkResp_qry_test(resp, err, "Expr date"); err != nil {
+			t.Error(err)
+		}
+		results = bo.JsonToSlice(resp.Recs, data.Location{})
+		if got := ids(results); !slices.Equal(got, []string{"005", "009"}) {
+			t.Errorf("Expr date: expected [005 009], got %v", got)
+		}
+
+		// SortKey Expr: len(city) desc, then city → Indianapolis, Jacksonville
+		resp, err = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{
+			BktName: qryTestBkt,
+			SortKeys: []bobb.SortKey{
+				{Expr: "len(city)", Dir: bobb.SortDescInt},
+				{Fld: "city", Dir: bobb.SortAscStr},
+			},
+			Top: 2,
+		})
+		if err := checkResp_qry_test(resp, err, "SortKey Expr"); err != nil {
+			t.Error(err)
+		}
+		results = bo.JsonToSlice(resp.Recs, data.Location{})
+		if got := ids(results); !slices.Equal(got, []string{"009", "010"}) {
+			t.Errorf("SortKey Expr: expected [009 010], got %v", got)
+		}
+
+		// Computed flds: string concat and float arithmetic added to result rec
+		resp, err = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{
+			BktName:  qryTestBkt,
+			StartKey: "001",
+			EndKey:   "001",
+			Computed: []bobb.ComputedFld{
+				{Fld: "label", Expr: "city + ', ' + st"},
+				{Fld: "weight", Expr: "(locationType + 2) * 1.5"},
+			},
+		})
+		if err := checkResp_qry_test(resp, err, "Computed"); err != nil {
+			t.Error(err)
+		}
+		if len(resp.Recs) != 1 {
+			t.Fatalf("Computed: expected 1 rec, got %d", len(resp.Recs))
+		}
+		var rec map[string]any
+		if err := json.Unmarshal(resp.Recs[0], &rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec["label"] != "Austin, TX" || rec["weight"] != 4.5 {
+			t.Errorf("Computed: expected label Austin, TX and weight 4.5, got %v, %v", rec["label"], rec["weight"])
+		}
+
+		// syntax error → validation failure
+		resp, _ = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{
+			BktName: qryTestBkt,
+			Criteria: []bobb.FindGroup{
+				{bobb.FindCondition{Expr: "len(city", Op: bobb.FindGreaterThan, ValInt: 7}},
+			},
+		})
+		if resp.Status != bobb.StatusFail {
+			t.Errorf("Expr syntax: expected StatusFail, got %s", resp.Status)
+		}
+
+		// evaluation error (string * int) → every rec in resp.Errs
+		resp, _ = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{
+			BktName:  qryTestBkt,
+			ErrLimit: -1,
+			Criteria: []bobb.FindGroup{
+				{bobb.FindCondition{Expr: "city * 2", Op: bobb.FindGreaterThan, ValInt: 0}},
+			},
+		})
+		if resp.Status != bobb.StatusWarning || len(resp.Errs) != len(qryLocs) || resp.Errs[0].ErrCode != bobb.ErrExpr {
+			t.Errorf("Expr eval error: expected StatusWarning with %d ErrExpr errs, got %s, %d errs", len(qryLocs), resp.Status, len(resp.Errs))
+		}
+	})
+
+	// -----------------------------------------------------------------------
+	t.Run("Projection", func(t *testing.T) {
+		// Get: only listed flds returned
+		resp, err := bo.Run(httpClient, bobb.OpGet, bobb.GetRequest{
+			BktName: qryTestBkt,
+			Keys:    []string{"001", "002"},
+			Fields:  []string{"id", "city"},
+		})
+		if err := checkResp_qry_test(resp, err, "Projection Get"); err != nil {
+			t.Error(err)
+		}
+		if len(resp.Recs) != 2 || string(resp.Recs[0]) != `{"id":"001","city":"Austin"}` {
+			t.Errorf("Projection Get: unexpected recs %q", resp.Recs)
+		}
+
+		// GetAll: nested flds keep type, renamed flds, missing flds omitted
+		resp, err = bo.Run(httpClient, bobb.OpGetAll, bobb.GetAllRequest{
+			BktName:  qryTestBkt,
+			StartKey: "003",
+			EndKey:   "003",
+			Fields:   []string{"id", "agent.name", "agentId=agent.id", "type=locationType", "missing"},
+		})
+		if err := checkResp_qry_test(resp, err, "Projection GetAll"); err != nil {
+			t.Error(err)
+		}
+		expected := `{"id":"003","agent":{"name":""},"agentId":0,"type":1}`
+		if len(resp.Recs) != 1 || string(resp.Recs[0]) != expected {
+			t.Errorf("Projection GetAll: expected %s, got %q", expected, resp.Recs)
+		}
+
+		// Qry: sort on fld not in projection, results unmarshal into struct
+		resp, err = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{
+			BktName:  qryTestBkt,
+			Criteria: []bobb.FindGroup{bo.Find(nil, "st", bobb.FindMatches, "TX")},
+			SortKeys: []bobb.SortKey{{Fld: "zip", Dir: bobb.SortDescStr}},
+			Fields:   []string{"id", "city"},
+		})
+		if err := checkResp_qry_test(resp, err, "Projection Qry"); err != nil {
+			t.Error(err)
+		}
+		results := bo.JsonToSlice(resp.Recs, data.Location{})
+		if got := ids(results); !slices.Equal(got, []string{"005", "001", "008"}) {
+			t.Errorf("Projection Qry: expected [005 001 008], got %v", got)
+		}
+		if results[0].City != "Austin" || results[0].Zip != "" {
+			t.Errorf("Projection Qry: expected only id and city, got %+v", results[0])
+		}
+
+		// invalid Fields entry → validation failure
+		resp, _ = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{BktName: qryTestBkt, Fields: []string{"a=", "b"}})
+		if resp.Status != bobb.StatusFail {
+			t.Errorf("Projection invalid: expected StatusFail, got %s", resp.Status)
+		}
+	})
+
+	// -----------------------------------------------------------------------
+	t.Run("Facets", func(t *testing.T) {
+		// all recs: st by count limit 2 (ties in value order), locationType by value (numeric)
+		resp, err := bo.Run(httpClient, bobb.OpFacet, bobb.FacetRequest{
+			Qry: bobb.QryRequest{BktName: qryTestBkt},
+			Facets: []bobb.FacetFld{
+				{Fld: "st", Limit: 2},
+				{Fld: "locationType", SortBy: bobb.FacetSortValue},
+			},
+		})
+		if err := checkResp_qry_test(resp, err, "Facets"); err != nil {
+			t.Fatal(err)
+		}
+		var facets []bobb.FacetResult
+		if err := json.Unmarshal(resp.Rec, &facets); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Recs != nil || resp.GetCnt != len(qryLocs) {
+			t.Errorf("Facets: expected no recs and GetCnt %d, got %d recs, GetCnt %d", len(qryLocs), len(resp.Recs), resp.GetCnt)
+		}
+		if got := fmt.Sprint(facets); got != "[{st 8 0 [{TX 3} {AZ 1}]} {locationType 3 0 [{1 4} {2 3} {3 3}]}]" {
+			t.Errorf("Facets: unexpected result %s", got)
+		}
+
+		// criteria, first page of recs in same request
+		resp, err = bo.Run(httpClient, bobb.OpFacet, bobb.FacetRequest{
+			Qry: bobb.QryRequest{
+				BktName:  qryTestBkt,
+				Criteria: []bobb.FindGroup{bo.Find(nil, "st", bobb.FindMatches, "TX")},
+				SortKeys: []bobb.SortKey{{Fld: "zip", Dir: bobb.SortAscStr}},
+				Top:      2,
+			},
+			Facets:      []bobb.FacetFld{{Fld: "city"}, {Fld: "nulltest"}},
+			IncludeRecs: true,
+		})
+		if err := checkResp_qry_test(resp, err, "Facets IncludeRecs"); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(resp.Rec, &facets); err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(facets); got != "[{city 2 0 [{Austin 2} {Houston 1}]} {nulltest 0 3 []}]" {
+			t.Errorf("Facets IncludeRecs: unexpected result %s", got)
+		}
+		results := bo.JsonToSlice(resp.Recs, data.Location{})
+		if got := ids(results); !slices.Equal(got, []string{"008", "001"}) {
+			t.Errorf("Facets IncludeRecs: expected [008 001], got %v", got)
+		}
+
+		// invalid SortBy → validation failure
+		resp, _ = bo.Run(httpClient, bobb.OpFacet, bobb.FacetRequest{
+			Qry:    bobb.QryRequest{BktName: qryTestBkt},
+			Facets: []bobb.FacetFld{{Fld: "st", SortBy: "bad"}},
+		})
+		if resp.Status != bobb.StatusFail {
+			t.Errorf("Facets invalid SortBy: expected StatusFail, got %s", resp.Status)
+		}
+	})
+
+	// -----------------------------------------------------------------------
+	t.Run("SavedQuery", func(t *testing.T) {
+		save := func(query bobb.SavedQuery) *bobb.Response {
+			resp, err := bo.Run(httpClient, bobb.OpSavedQuery, bobb.SavedQueryRequest{Operation: bobb.SavedQuerySave, Query: query})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return resp
+		}
+		runSaved := func(name string, params map[string]any) *bobb.Response {
+			resp, err := bo.Run(httpClient, bobb.OpRunSavedQry, bobb.RunSavedQueryRequest{Name: name, Params: params})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return resp
+		}
+		byState := bobb.SavedQuery{
+			Name: "qry_test_by_state",
+			Params: []bobb.QryParam{
+				{Name: "state", Type: bobb.QryParamStr, Required: true},
+				{Name: "minType", Type: bobb.QryParamInt},
+			},
+			Qry: json.RawMessage(`{"BktName": "` + qryTestBkt + `",
+				"Criteria": [[{"Fld": "st", "Op": "matches", "ValStr": "$state"},
+				              {"Fld": "locationType", "Op": "greaterthan", "ValInt": "$minType"}]],
+				"SortKeys": [{"Fld": "zip", "Dir": "ascstr"}]}`),
+		}
+		byCities := bobb.SavedQuery{
+			Name:   "qry_test_by_cities",
+			Params: []bobb.QryParam{{Name: "cities", Type: bobb.QryParamStrList, Default: []string{"boston"}}},
+			Qry:    json.RawMessage(`{"BktName": "` + qryTestBkt + `", "Criteria": [[{"Fld": "city", "Op": "instrlist", "StrList": "$cities"}]]}`),
+		}
+		defer bo.Run(httpClient, bobb.OpSavedQuery, bobb.SavedQueryRequest{Operation: bobb.SavedQueryDelete, Name: byState.Name})
+		defer bo.Run(httpClient, bobb.OpSavedQuery, bobb.SavedQueryRequest{Operation: bobb.SavedQueryDelete, Name: byCities.Name})
+		for _, query := range []bobb.SavedQuery{byState, byCities} {
+			if err := checkResp_qry_test(save(query), nil, "SavedQuery save "+query.Name); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// TX: 001 type 1, 005 type 2, 008 type 2; sorted by zip
+		tests := []struct {
+			name     string
+			params   map[string]any
+			expected []string
+		}{
+			{byState.Name, map[string]any{"state": "TX"}, []string{"008", "001", "005"}},
+			{byState.Name, map[string]any{"state": "TX", "minType": 1}, []string{"008", "005"}},
+			{byState.Name, map[string]any{"state": "TX", "minType": 1}, []string{"008", "005"}}, // cached
+			{byState.Name, map[string]any{"state": "co", "minType": 2}, []string{"004"}},
+			{byCities.Name, nil, []string{"002"}},
+			{byCities.Name, map[string]any{"cities": []string{"Austin", "Denver"}}, []string{"001", "004", "005"}},
+		}
+		for _, test := range tests {
+			resp := runSaved(test.name, test.params)
+			if err := checkResp_qry_test(resp, nil, "SavedQuery run"); err != nil {
+				t.Error(err)
+				continue
+			}
+			if got := ids(bo.JsonToSlice(resp.Recs, data.Location{})); !slices.Equal(got, test.expected) {
+				t.Errorf("SavedQuery %s %v: expected %v, got %v", test.name, test.params, test.expected, got)
+			}
+		}
+
+		// param errors → validation failure
+		for desc, params := range map[string]map[string]any{
+			"missing required": {"minType": 1},
+			"wrong type":       {"state": "TX", "minType": "1"},
+			"not integer":      {"state": "TX", "minType": 1.5},
+			"not declared":     {"state": "TX", "maxType": 3},
+		} {
+			if resp := runSaved(byState.Name, params); resp.Status != bobb.StatusFail {
+				t.Errorf("SavedQuery %s: expected StatusFail, got %s", desc, resp.Status)
+			}
+		}
+
+		// invalid templates are rejected when saved
+		badQueries := map[string]bobb.SavedQuery{
+			"undeclared placeholder": {Name: "qry_test_bad", Qry: json.RawMessage(`{"BktName": "x", "StartKey": "$start"}`)},
+			"unused param":           {Name: "qry_test_bad", Params: []bobb.QryParam{{Name: "p", Type: bobb.QryParamStr}}, Qry: json.RawMessage(`{"BktName": "x"}`)},
+			"invalid type":           {Name: "qry_test_bad", Params: []bobb.QryParam{{Name: "p", Type: "date"}}, Qry: json.RawMessage(`{"StartKey": "$p"}`)},
+			"invalid criteria":       {Name: "qry_test_bad", Qry: json.RawMessage(`{"Criteria": [[{"Fld": "st", "Op": "bad"}]]}`)},
+			"type mismatch":          {Name: "qry_test_bad", Params: []bobb.QryParam{{Name: "p", Type: bobb.QryParamStr}}, Qry: json.RawMessage(`{"Top": "$p"}`)},
+		}
+		for desc, query := range badQueries {
+			if resp := save(query); resp.Status != bobb.StatusFail {
+				t.Errorf("SavedQuery save %s: expected StatusFail, got %s", desc, resp.Status)
+			}
+		}
+
+		// get, list, replace, delete
+		resp, err := bo.Run(httpClient, bobb.OpSavedQuery, bobb.SavedQueryRequest{Operation: bobb.SavedQueryGet, Name: byState.Name})
+		if err := checkResp_qry_test(resp, err, "SavedQuery get"); err != nil {
+			t.Fatal(err)
+		}
+		var got bobb.SavedQuery
+		if err := json.Unmarshal(resp.Rec, &got); err != nil || got.Name != byState.Name || len(got.Params) != 2 {
+			t.Errorf("SavedQuery get: unexpected result %s", resp.Rec)
+		}
+		resp, err = bo.Run(httpClient, bobb.OpSavedQuery, bobb.SavedQueryRequest{Operation: bobb.SavedQueryList})
+		if err := checkResp_qry_test(resp, err, "SavedQuery list"); err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, 0, len(resp.Recs))
+		for _, rec := range resp.Recs {
+			var query bobb.SavedQuery
+			json.Unmarshal(rec, &query)
+			if query.Name == byState.Name || query.Name == byCities.Name {
+				names = append(names, query.Name)
+			}
+		}
+		if !slices.Equal(names, []string{byCities.Name, byState.Name}) {
+			t.Errorf("SavedQuery list: expected both test queries in name order, got %v", names)
+		}
+
+		byCities.Params[0].Default = []string{"houston"} // replaced query must not use cached entry
+		save(byCities)
+		if got := ids(bo.JsonToSlice(runSaved(byCities.Name, nil).Recs, data.Location{})); !slices.Equal(got, []string{"008"}) {
+			t.Errorf("SavedQuery replaced: expected [008], got %v", got)
+		}
+
+		resp, err = bo.Run(httpClient, bobb.OpSavedQuery, bobb.SavedQueryRequest{Operation: bobb.SavedQueryDelete, Name: byCities.Name})
+		if err := checkResp_qry_test(resp, err, "SavedQuery delete"); err != nil {
+			t.Error(err)
+		}
+		if resp := runSaved(byCities.Name, nil); resp.Status != bobb.StatusFail {
+			t.Errorf("SavedQuery deleted: expected StatusFail, got %s", resp.Status)
+		}
+	})
+
+	// -----------------------------------------------------------------------
+	t.Run("QryStr", func(t *testing.T) {
+		tests := []struct {
+			qry      string
+			expected []string
+		}{
+			// TX/CO with type >= 2: 005, 008, 004; locationType inferred int, ties by zip
+			{"select id, city from qry_test where st in ('TX', 'CO') and locationType >= 2 order by locationType desc, zip limit 3", []string{"004", "008", "005"}},
+			{"FROM qry_test WHERE NOT (st = 'TX' OR locationType = 1) ORDER BY id DESC", []string{"010", "007", "004", "002"}},
+			{"from qry_test keys prefix '00' where nulltest is not null", []string{"009"}},
+			{"from qry_test where `len(city)` > 7 order by city", []string{"006", "007", "009", "010"}},
+			{"from qry_test where city regex '^(austin|denver)$' and not zip startswith '787'", []string{"004"}},
+			{"from qry_test where city between 'b' and 'd' or zip = '86001' keys from '002' to '006'", []string{"002", "003", "006"}},
+		}
+		for _, test := range tests {
+			resp, err := bo.QryStr(httpClient, test.qry)
+			if err := checkResp_qry_test(resp, err, "QryStr "+test.qry); err != nil {
+				t.Error(err)
+				continue
+			}
+			if got := ids(bo.JsonToSlice(resp.Recs, data.Location{})); !slices.Equal(got, test.expected) {
+				t.Errorf("QryStr %q: expected %v, got %v", test.qry, test.expected, got)
+			}
+		}
+
+		resp, err := bo.QryStr(httpClient, "select count(*) from qry_test where city between 'b' and 'd'")
+		if err := checkResp_qry_test(resp, err, "QryStr count"); err != nil {
+			t.Error(err)
+		} else if resp.GetCnt != 2 || resp.Recs != nil {
+			t.Errorf("QryStr count: expected GetCnt 2 and no recs, got %d, %d recs", resp.GetCnt, len(resp.Recs))
+		}
+
+		// compile only: joins placed by where clause use, sort types from keywords
+		resp, err = bo.Run(httpClient, bobb.OpQryStr, bobb.QryStrRequest{
+			Qry:     "from qry_test left join other on otherId set x = y, z = w where x = 'a' order by `len(city)` desc, z str",
+			Compile: true,
+		})
+		if err := checkResp_qry_test(resp, err, "QryStr compile"); err != nil {
+			t.Fatal(err)
+		}
+		var compiled bobb.QryRequest
+		if err := json.Unmarshal(resp.Rec, &compiled); err != nil {
+			t.Fatal(err)
+		}
+		if len(compiled.JoinsBeforeFind) != 1 || compiled.JoinsBeforeFind[0].ToFld != "x" || !compiled.JoinsBeforeFind[0].UseDefault ||
+			len(compiled.JoinsAfterFind) != 1 || compiled.JoinsAfterFind[0].ToFld != "z" {
+			t.Errorf("QryStr compile: unexpected joins %+v %+v", compiled.JoinsBeforeFind, compiled.JoinsAfterFind)
+		}
+		if keys := compiled.SortKeys; len(keys) != 2 || keys[0].Expr != "len(city)" || keys[0].Dir != bobb.SortDescInt || keys[1].Fld != "z" || keys[1].Dir != bobb.SortAscStr {
+			t.Errorf("QryStr compile: unexpected sort keys %+v", keys)
+		}
+
+		// syntax errors → StatusFail, Msg has position and what was expected
+		syntaxErrs := map[string]string{
+			"from qry_test where st = ":               "pos 25, near end of query: expected 'string', integer or `expression` after =",
+			"from qry_test order city":                `pos 20, near "city": expected by`,
+			"select id from qry_test where limit = 5": `expected fld, `,
+			"from qry_test where st in ('TX', 5)":     "in list values must all be strings or all integers",
+			"from qry_test where locationType > 1.5":  "only integers are supported",
+			"from qry_test where st = 'TX":            "string not terminated",
+			"from qry_test limit 5 limit 6":           "limit clause used more than once",
+			"qry_test where st = 'TX'":                "expected from (or select) at start of query",
+			"from qry_test where st ~ 'TX'":           "unexpected character",
+			"from qry_test where st like 'TX'":        "expected compare after st",
+			"from qry_test where city regex '[a'":     "invalid Criteria group 0",
+		}
+		for qry, msg := range syntaxErrs {
+			resp, err := bo.QryStr(httpClient, qry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Status != bobb.StatusFail || !strings.Contains(resp.Msg, msg) {
+				t.Errorf("QryStr %q: expected StatusFail with msg containing %q, got %s %q", qry, msg, resp.Status, resp.Msg)
+			}
+		}
+	})
+
+	// -----------------------------------------------------------------------
+	t.Run("Union", func(t *testing.T) {
+		unionBkts := []string{"qry_test_union_2024", "qry_test_union_2025"}
+		cleanup := func() {
+			for _, bktName := range unionBkts {
+				bo.Run(httpClient, bobb.OpBktAdmin, bobb.BktAdminRequest{Operation: bobb.AdminDropBkt, BktName: bktName})
+			}
+		}
+		cleanup()
+		defer cleanup()
+		unionRecs := [][][]byte{
+			{[]byte(`{"id":"a1","amt":50}`), []byte(`{"id":"a2","amt":10}`), []byte(`{"id":"a3","amt":30}`)},
+			{[]byte(`{"id":"a1","amt":30}`), []byte(`{"id":"b2","amt":40}`), []byte(`{"id":"b3","amt":5}`)},
+		}
+		// index, inverted and put log bkts of 2024 match BktPrefix but are skipped
+		resp, err := bo.Run(httpClient, bobb.OpIndexSetting, bobb.IndexSettingRequest{IndexSettings: []bobb.IndexSetting{{
+			DataBkt:  unionBkts[0],
+			IndexBkt: unionBkts[0] + "_amt_index",
+			KeyFlds:  []bobb.FldFormat{{FldName: "amt", FldType: bobb.FldTypeInt, Length: 4, UseDefault: bobb.DefaultAlways}},
+		}}})
+		if err := checkResp_qry_test(resp, err, "Union - IndexSetting"); err != nil {
+			t.Fatal(err)
+		}
+		for i, bktName := range unionBkts {
+			resp, err := bo.Put(httpClient, bktName, unionRecs[i], nil, bo.PutLogPut)
+			if err := checkResp_qry_test(resp, err, "Union - Put "+bktName); err != nil {
+				t.Fatal(err)
+			}
+		}
+		type unionRec struct {
+			Id  string `json:"id"`
+			Amt int    `json:"amt"`
+			Bkt string `json:"_bkt"`
+		}
+		recStrs := func(recs [][]byte) []string {
+			result := make([]string, len(recs))
+			for i, rec := range recs {
+				var r unionRec
+				json.Unmarshal(rec, &r)
+				result[i] = fmt.Sprintf("%s %d %s", r.Id, r.Amt, strings.TrimPrefix(r.Bkt, "qry_test_union_"))
+			}
+			return result
+		}
+
+		// merged sort across bkts, Top applies to merged result, amt tie (30) in bkt order
+		resp, err = bo.Run(httpClient, bobb.OpUnionQry, bobb.UnionQryRequest{
+			BktPrefix: "qry_test_union_",
+			Qry: bobb.QryRequest{
+				Criteria: []bobb.FindGroup{bo.Find(nil, "amt", bobb.FindGreaterThan, 8)},
+				SortKeys: []bobb.SortKey{{Fld: "amt", Dir: bobb.SortDescInt}},
+				Top:      4,
+				Explain:  true,
+			},
+		})
+		if err := checkResp_qry_test(resp, err, "Union sorted"); err != nil {
+			t.Fatal(err)
+		}
+		if got, expected := recStrs(resp.Recs), []string{"a1 50 2024", "b2 40 2025", "a3 30 2024", "a1 30 2025"}; !slices.Equal(got, expected) {
+			t.Errorf("Union sorted: expected %q, got %q", expected, got)
+		}
+		if resp.Stats.KeysScanned != 6 || !strings.HasPrefix(resp.Stats.Plan, "union 2 bkts") {
+			t.Errorf("Union sorted: expected 6 keys scanned and union plan, got %d, %q", resp.Stats.KeysScanned, resp.Stats.Plan)
+		}
+		// system bkts are not data bkts
+		resp, _ = bo.Run(httpClient, bobb.OpUnionQry, bobb.UnionQryRequest{BktPrefix: bobb.IndexSettingsBkt})
+		if resp.Status != bobb.StatusFail {
+			t.Errorf("Union index_settings: expected StatusFail (no bkts), got %s", resp.Status)
+		}
+
+		// no sort keys: bkt then key order, source bkt usable in criteria, SrcBktFld added to Fields
+		resp, err = bo.Run(httpClient, bobb.OpUnionQry, bobb.UnionQryRequest{
+			BktNames:  []string{unionBkts[1], unionBkts[0]},
+			SrcBktFld: "src",
+			Qry: bobb.QryRequest{
+				Criteria: []bobb.FindGroup{
+					bo.Find(nil, "src", bobb.FindEndsWith, "2025"),
+					bo.Find(nil, "amt", bobb.FindLessThan, 20),
+				},
+				Fields: []string{"id"},
+			},
+		})
+		if err := checkResp_qry_test(resp, err, "Union BktNames"); err != nil {
+			t.Fatal(err)
+		}
+		got := make([]string, len(resp.Recs))
+		for i, rec := range resp.Recs {
+			got[i] = string(rec)
+		}
+		expected := []string{
+			`{"id":"a1","src":"qry_test_union_2025"}`, `{"id":"b2","src":"qry_test_union_2025"}`,
+			`{"id":"b3","src":"qry_test_union_2025"}`, `{"id":"a2","src":"qry_test_union_2024"}`,
+		}
+		if !slices.Equal(got, expected) {
+			t.Errorf("Union BktNames: expected %q, got %q", expected, got)
+		}
+
+		// invalid requests
+		invalid := map[string]bobb.UnionQryRequest{
+			"no bkts":      {Qry: bobb.QryRequest{}},
+			"prefix none":  {BktPrefix: "qry_test_nosuchbkt"},
+			"missing bkt":  {BktNames: []string{unionBkts[0], "qry_test_nosuchbkt"}},
+			"index not ok": {BktNames: unionBkts, Qry: bobb.QryRequest{IndexBkt: qryZipIndex}},
+		}
+		for name, req := range invalid {
+			resp, _ := bo.Run(httpClient, bobb.OpUnionQry, req)
+			if resp.Status != bobb.StatusFail {
+				t.Errorf("Union %s: expected StatusFail, got %s", name, resp.Status)
+			}
+		}
+	})
+
+	// -----------------------------------------------------------------------
+	t.Run("Sample", func(t *testing.T) {
+		sample := func(req bobb.SampleRequest) ([]string, bobb.SampleResult) {
+			t.Helper()
+			var result bobb.SampleResult
+			resp, err := bo.Run(httpClient, bobb.OpSample, req)
+			if err := checkResp_qry_test(resp, err, "Sample "+req.Method); err != nil {
+				t.Error(err)
+				return nil, result
+			}
+			json.Unmarshal(resp.Rec, &result)
+			return ids(bo.JsonToSlice(resp.Recs, data.Location{})), result
+		}
+		for _, method := range []string{bobb.SampleReservoir, bobb.SampleSeek} {
+			// same seed, same sample, returned in key order
+			req := bobb.SampleRequest{BktName: qryTestBkt, Count: 4, Method: method, Seed: 42}
+			got, result := sample(req)
+			again, _ := sample(req)
+			if len(got) == 0 || len(got) > 4 || !slices.Equal(got, again) || !slices.IsSorted(got) {
+				t.Errorf("Sample %s: expected same sorted sample of up to 4 recs, got %v and %v", method, got, again)
+			}
+			if result.Seed != 42 || result.Method != method {
+				t.Errorf("Sample %s: unexpected result %+v", method, result)
+			}
+
+			// criteria and key range: TX recs 001-006 are 001, 004, 005
+			req = bobb.SampleRequest{BktName: qryTestBkt, Count: 5, Method: method, StartKey: "001", EndKey: "006",
+				Criteria: []bobb.FindGroup{bo.Find(nil, "st", bobb.FindMatches, "TX")}}
+			got, result = sample(req)
+			for _, id := range got {
+				if !slices.Contains([]string{"001", "004", "005"}, id) {
+					t.Errorf("Sample %s criteria: unexpected rec %s in %v", method, id, got)
+				}
+			}
+			if result.Seed == 0 {
+				t.Errorf("Sample %s: expected generated seed in result", method)
+			}
+		}
+
+		// reservoir returns all matching recs when Count exceeds matches
+		got, result := sample(bobb.SampleRequest{BktName: qryTestBkt, Count: 20,
+			Criteria: []bobb.FindGroup{bo.Find(nil, "st", bobb.FindMatches, "TX")}})
+		if len(got) != result.Matched || result.Scanned != len(qryLocs) {
+			t.Errorf("Sample all: expected %d recs of %d scanned, got %v %+v", result.Matched, len(qryLocs), got, result)
+		}
+
+		resp, _ := bo.Run(httpClient, bobb.OpSample, bobb.SampleRequest{BktName: qryTestBkt})
+		if resp.Status != bobb.StatusFail {
+			t.Errorf("Sample no Count: expected StatusFail, got %s", resp.Status)
+		}
+	})
+
+	// -----------------------------------------------------------------------
+	t.Run("Reverse", func(t *testing.T) {
+		// Reverse with Limit 3: 010, 009, 008; NextKey 007 is EndKey of next page
+		resp, err := bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{
+			BktName: qryTestBkt,
+			Limit:   3,
+			Reverse: true,
+		})
+		if err := checkResp_qry_test(resp, err, "Reverse"); err != nil {
+			t.Error(err)
+		}
+		results := bo.JsonToSlice(resp.Recs, data.Location{})
+		if got := ids(results); !slices.Equal(got, []string{"010", "009", "008"}) {
+			t.Errorf("Reverse: expected [010 009 008], got %v", got)
+		}
+		if resp.NextKey != "007" {
+			t.Errorf("Reverse: expected NextKey 007, got %s", resp.NextKey)
+		}
+
+		// next page, range 003 to NextKey: 007, 006, 005, 004, 003
+		resp, err = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{
+			BktName:  qryTestBkt,
+			StartKey: "003",
+			EndKey:   resp.NextKey,
+			Reverse:  true,
+		})
+		if err := checkResp_qry_test(resp, err, "Reverse next page"); err != nil {
+			t.Error(err)
+		}
+		results = bo.JsonToSlice(resp.Recs, data.Location{})
+		if got := ids(results); !slices.Equal(got, []string{"007", "006", "005", "004", "003"}) {
+			t.Errorf("Reverse next page: expected [007 006 005 004 003], got %v", got)
+		}
+
+		// Reverse with prefix "00" → last key with prefix is 009
+		resp, err = bo.Run(httpClient, bobb.OpGetAllKeys, bobb.GetAllKeysRequest{
+			BktName:  qryTestBkt,
+			StartKey: "00",
+			EndKey:   "00",
+			Limit:    2,
+			Reverse:  true,
+		})
+		if err := checkResp_qry_test(resp, err, "Reverse prefix"); err != nil {
+			t.Error(err)
+		}
+		if len(resp.Recs) != 2 || string(resp.Recs[0]) != "009" || string(resp.Recs[1]) != "008" {
+			t.Errorf("Reverse prefix: expected keys 009, 008, got %q", resp.Recs)
+		}
+
+		// Reverse through index: zip index, highest zip is 86001 (006)
+		resp, err = bo.Run(httpClient, bobb.OpGetAll, bobb.GetAllRequest{
+			BktName:  qryTestBkt,
+			IndexBkt: qryZipIndex,
+			Limit:    1,
+			Reverse:  true,
+		})
+		if err := checkResp_qry_test(resp, err, "Reverse index"); err != nil {
+			t.Error(err)
+		}
+		results = bo.JsonToSlice(resp.Recs, data.Location{})
+		if len(results) != 1 || results[0].Id != "006" {
+			t.Errorf("Reverse index: expected 006, got %v", ids(results))
+		}
+	})
+
 	// -----------------------------------------------------------------------
 	t.Run("CountOnly", func(t *testing.T) {
 		// locationType == 1: 001, 003, 006, 009 = 4; CountOnly returns count, Recs is nil
@@ -697,6 +1458,377 @@ func TestQry(t *testing.T) {
 		}
 	})
 
+	// -----------------------------------------------------------------------
+	t.Run("SubQry", func(t *testing.T) {
+		// requests: req001 → 001 routine, req002 → 005 urgent, req003 → 008 routine
+		routine := bobb.QryRequest{BktName: qryJoinBkt, Criteria: []bobb.FindGroup{bo.Find(nil, "description", bobb.FindContains, "routine")}}
+		type2 := bobb.QryRequest{BktName: qryTestBkt, Criteria: []bobb.FindGroup{bo.Find(nil, "locationType", bobb.FindEquals, 2)}}
+		denver := bobb.QryRequest{BktName: qryTestBkt, Criteria: []bobb.FindGroup{bo.Find(nil, "city", bobb.FindMatches, "Denver")}}
+		urgent := bobb.QryRequest{BktName: qryJoinBkt, Criteria: []bobb.FindGroup{bo.Find(nil, "description", bobb.FindContains, "urgent")}}
+		tests := []struct {
+			desc      string
+			bkt       string
+			condition bobb.FindCondition
+			expected  []string
+		}{
+			{"semi-join, locations with a routine request", qryTestBkt,
+				bobb.FindCondition{Fld: "id", Op: bobb.FindInStrList, SubQry: &bobb.SubQry{Qry: routine, Fld: "locationId"}},
+				[]string{"001", "008"}},
+			{"anti-join, requests whose location is not type 2 (key fld default)", qryJoinBkt,
+				bobb.FindCondition{Fld: "locationId", Op: bobb.FindInStrList, Not: true, SubQry: &bobb.SubQry{Qry: type2}},
+				[]string{"req001"}},
+			{"int values, locations with same type as Denver", qryTestBkt,
+				bobb.FindCondition{Fld: "locationType", Op: bobb.FindInIntList, SubQry: &bobb.SubQry{Qry: denver, Fld: "locationType"}},
+				[]string{"004", "007", "010"}},
+			{"exists, subquery has recs", qryJoinBkt,
+				bobb.FindCondition{Op: bobb.FindExists, SubQry: &bobb.SubQry{Qry: urgent}},
+				[]string{"req001", "req002", "req003"}},
+			{"not exists, subquery has recs", qryJoinBkt,
+				bobb.FindCondition{Op: bobb.FindExists, Not: true, SubQry: &bobb.SubQry{Qry: urgent}},
+				[]string{}},
+		}
+		for _, test := range tests {
+			resp, err := bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{BktName: test.bkt, Criteria: []bobb.FindGroup{{test.condition}}})
+			if err := checkResp_qry_test(resp, err, "SubQry "+test.desc); err != nil {
+				t.Error(err)
+				continue
+			}
+			got := make([]string, len(resp.Recs))
+			for i, rec := range resp.Recs {
+				var r struct{ Id string }
+				json.Unmarshal(rec, &r)
+				got[i] = r.Id
+			}
+			if !slices.Equal(got, test.expected) {
+				t.Errorf("SubQry %s: expected %v, got %v", test.desc, test.expected, got)
+			}
+		}
+
+		// invalid subqueries → validation failure
+		invalid := map[string]bobb.FindCondition{
+			"with StrList": {Fld: "id", Op: bobb.FindInStrList, StrList: []string{"001"}, SubQry: &bobb.SubQry{Qry: routine}},
+			"invalid op":   {Fld: "id", Op: bobb.FindMatches, SubQry: &bobb.SubQry{Qry: routine}},
+			"missing bkt":  {Fld: "id", Op: bobb.FindInStrList, SubQry: &bobb.SubQry{Qry: bobb.QryRequest{BktName: "qry_test_no_such_bkt"}}},
+			"subqry error": {Fld: "id", Op: bobb.FindInStrList, SubQry: &bobb.SubQry{Qry: bobb.QryRequest{BktName: qryTestBkt,
+				Criteria: []bobb.FindGroup{{bobb.FindCondition{Fld: "nulltest", Op: bobb.FindMatches, ValStr: "set", UseDefault: bobb.DefaultNever}}}}}},
+		}
+		for desc, condition := range invalid {
+			resp, _ := bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{BktName: qryTestBkt, Criteria: []bobb.FindGroup{{condition}}})
+			if resp.Status != bobb.StatusFail {
+				t.Errorf("SubQry %s: expected StatusFail, got %s", desc, resp.Status)
+			}
+		}
+
+		// subquery recs skipped under its ErrLimit → outer warning with the subquery errs
+		skipping := invalid["subqry error"]
+		skipping.SubQry.Qry.ErrLimit = -1
+		resp, _ := bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{BktName: qryTestBkt, Criteria: []bobb.FindGroup{{skipping}}})
+		if resp.Status != bobb.StatusWarning || len(resp.Errs) == 0 || !strings.HasPrefix(resp.Errs[0].Msg, "SubQry on "+qryTestBkt) {
+			t.Errorf("SubQry warning: expected StatusWarning with subquery errs, got %s %v", resp.Status, resp.Errs)
+		}
+	})
+
+	// -----------------------------------------------------------------------
+	t.Run("JoinsIndexChain", func(t *testing.T) {
+		const (
+			zipBkt   = "qry_test_zipinfo"
+			zipIndex = "qry_test_zipinfo_zip_index"
+		)
+		cleanup := func() {
+			bo.Run(httpClient, bobb.OpBktAdmin, bobb.BktAdminRequest{Operation: bobb.AdminDropBkt, BktName: zipBkt})
+		}
+		cleanup()
+		defer cleanup()
+
+		setting := bobb.IndexSetting{
+			DataBkt:        zipBkt,
+			IndexBkt:       zipIndex,
+			FldSeparator:   "|",
+			KeySuffixWidth: 4,
+			KeyFlds:        []bobb.FldFormat{{FldName: "zip", FldType: bobb.FldTypeStr, Length: 10, StrOption: bobb.StrAsIs, UseDefault: bobb.DefaultNever}},
+		}
+		resp, err := bo.Run(httpClient, bobb.OpIndexSetting, bobb.IndexSettingRequest{IndexSettings: []bobb.IndexSetting{setting}})
+		if err := checkResp_qry_test(resp, err, "JoinsIndexChain - IndexSetting"); err != nil {
+			t.Fatal(err)
+		}
+		zipRecs := [][]byte{ // keyed by id, joined by zip through index
+			[]byte(`{"id":"z1","zip":"78701","county":"Travis"}`),
+			[]byte(`{"id":"z2","zip":"78702","county":"Travis"}`),
+			[]byte(`{"id":"z3","zip":"77001","county":"Harris"}`),
+		}
+		resp, err = bo.Put(httpClient, zipBkt, zipRecs, nil)
+		if err := checkResp_qry_test(resp, err, "JoinsIndexChain - Put"); err != nil {
+			t.Fatal(err)
+		}
+
+		// request > location (whole rec) > zip info (by location.zip through index)
+		resp, err = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{
+			BktName: qryJoinBkt,
+			JoinsBeforeFind: []bobb.Join{
+				{JoinBkt: qryTestBkt, JoinFld: "locationId", ToFld: "location"},
+				{IndexBkt: zipIndex, JoinFld: "location.zip", FromFld: "county", ToFld: "county"},
+			},
+			Criteria: []bobb.FindGroup{bo.Find(nil, "county", bobb.FindMatches, "travis")},
+			Explain:  true,
+		})
+		if err := checkResp_qry_test(resp, err, "JoinsIndexChain"); err != nil {
+			t.Fatal(err)
+		}
+		type reqWithJoins struct {
+			Id       string        `json:"id"`
+			Location data.Location `json:"location"`
+			County   string        `json:"county"`
+		}
+		got := make([]string, len(resp.Recs))
+		for i, rec := range resp.Recs {
+			var r reqWithJoins
+			json.Unmarshal(rec, &r)
+			got[i] = fmt.Sprintf("%s %s %s %s", r.Id, r.Location.Id, r.Location.City, r.County)
+		}
+		if expected := []string{"req001 001 Austin Travis", "req002 005 Austin Travis"}; !slices.Equal(got, expected) {
+			t.Errorf("JoinsIndexChain: expected %q, got %q", expected, got)
+		}
+		if resp.Stats.JoinLookups != 3*3 { // location get, zip index seek, zip info get for each request
+			t.Errorf("JoinsIndexChain: expected 9 join lookups, got %d", resp.Stats.JoinLookups)
+		}
+
+		// zip not in index → error, or skipped with UseDefault
+		joins := []bobb.Join{
+			{JoinBkt: qryTestBkt, JoinFld: "locationId", ToFld: "location"},
+			{JoinBkt: zipBkt, IndexBkt: zipIndex, JoinFld: "location.zip", FromFld: "county", ToFld: "county"},
+		}
+		resp, _ = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{BktName: qryTestBkt, StartKey: "004", EndKey: "004",
+			JoinsAfterFind: []bobb.Join{{IndexBkt: zipIndex, JoinFld: "zip", ToFld: "zipInfo"}}})
+		if resp.Status != bobb.StatusFail || len(resp.Errs) != 1 || resp.Errs[0].ErrCode != bobb.ErrJoinKey {
+			t.Errorf("JoinsIndexChain zip not found: expected fail with %s err, got %s %v", bobb.ErrJoinKey, resp.Status, resp.Errs)
+		}
+		joins[1].UseDefault = true
+		resp, err = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{BktName: qryJoinBkt, JoinsAfterFind: joins})
+		if err := checkResp_qry_test(resp, err, "JoinsIndexChain UseDefault"); err != nil {
+			t.Fatal(err)
+		}
+		if resp.GetCnt != 3 {
+			t.Errorf("JoinsIndexChain UseDefault: expected 3 recs, got %d", resp.GetCnt)
+		}
+
+		// index of a different bkt → validation failure
+		joins[1].JoinBkt = qryTestBkt
+		resp, _ = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{BktName: qryJoinBkt, JoinsAfterFind: joins})
+		if resp.Status != bobb.StatusFail {
+			t.Errorf("JoinsIndexChain wrong JoinBkt: expected StatusFail, got %s", resp.Status)
+		}
+
+		// missing JoinBkt fails at compile time, UseDefault does not hide it
+		resp, _ = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{BktName: qryJoinBkt,
+			JoinsAfterFind: []bobb.Join{{JoinBkt: "qry_test_nosuchbkt", JoinFld: "locationId", ToFld: "location", UseDefault: true}}})
+		if resp.Status != bobb.StatusFail || len(resp.Errs) != 1 || resp.Errs[0].ErrCode != bobb.ErrJoinBkt {
+			t.Errorf("JoinsIndexChain missing JoinBkt: expected fail with %s err, got %s %v", bobb.ErrJoinBkt, resp.Status, resp.Errs)
+		}
+
+		// JoinFld containing "." is a top level fld if found
+		resp, err = bo.Put(httpClient, zipBkt, [][]byte{[]byte(`{"id":"z4","zip":"99999","zip.ref":"z3"}`)}, nil)
+		if err := checkResp_qry_test(resp, err, "JoinsIndexChain - Put z4"); err != nil {
+			t.Fatal(err)
+		}
+		resp, err = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{BktName: zipBkt, StartKey: "z4", EndKey: "z4",
+			JoinsAfterFind: []bobb.Join{{JoinBkt: zipBkt, JoinFld: "zip.ref", FromFld: "county", ToFld: "refCounty"}}})
+		if err := checkResp_qry_test(resp, err, "JoinsIndexChain dotted JoinFld"); err != nil {
+			t.Fatal(err)
+		}
+		var ref struct{ RefCounty string }
+		if len(resp.Recs) != 1 || json.Unmarshal(resp.Recs[0], &ref) != nil || ref.RefCounty != "Harris" {
+			t.Errorf("JoinsIndexChain dotted JoinFld: expected refCounty Harris, got %q", resp.Recs)
+		}
+	})
+
+	// -----------------------------------------------------------------------
+	t.Run("ChildJoins", func(t *testing.T) {
+		const (
+			orderBkt     = "qry_test_order"
+			itemBkt      = "qry_test_order_item"
+			itemOrderIdx = "qry_test_order_item_orderid_index"
+		)
+		cleanup := func() {
+			for _, bkt := range []string{orderBkt, itemBkt, itemOrderIdx, itemOrderIdx + "_inverted"} {
+				bo.DeleteBkt(httpClient, bkt)
+			}
+			bo.Run(httpClient, bobb.OpBktAdmin, bobb.BktAdminRequest{Operation: bobb.AdminDropIndex, IndexBkt: itemOrderIdx})
+		}
+		cleanup()
+		defer cleanup()
+
+		setting := bobb.IndexSetting{
+			DataBkt:        itemBkt,
+			IndexBkt:       itemOrderIdx,
+			FldSeparator:   "|", // index keys "ord1  |0001", padded KeyFld value encoded by server
+			KeySuffixWidth: 4,
+			KeyFlds:        []bobb.FldFormat{{FldName: "orderId", FldType: bobb.FldTypeStr, Length: 6, StrOption: bobb.StrAsIs, UseDefault: bobb.DefaultNever}},
+		}
+		resp, err := bo.Run(httpClient, bobb.OpIndexSetting, bobb.IndexSettingRequest{IndexSettings: []bobb.IndexSetting{setting}})
+		if err := checkResp_qry_test(resp, err, "ChildJoins - IndexSetting"); err != nil {
+			t.Fatal(err)
+		}
+		orders := []data.Order{{Id: "ord1"}, {Id: "ord2"}, {Id: "ord3"}} // ord3 has no items
+		items := []data.OrderItem{
+			{Id: "ord1_1", OrderId: "ord1", ItemNo: 1, ProductId: "p1", Qty: 5},
+			{Id: "ord1_2", OrderId: "ord1", ItemNo: 2, ProductId: "p2", Qty: 1},
+			{Id: "ord1_3", OrderId: "ord1", ItemNo: 3, ProductId: "p3", Qty: 3},
+			{Id: "ord2_1", OrderId: "ord2", ItemNo: 1, ProductId: "p1", Qty: 2},
+		}
+		resp, err = bo.Put(httpClient, orderBkt, bo.SliceToJson(orders), nil)
+		if err := checkResp_qry_test(resp, err, "ChildJoins - Put orders"); err != nil {
+			t.Fatal(err)
+		}
+		resp, err = bo.Put(httpClient, itemBkt, bo.SliceToJson(items), nil)
+		if err := checkResp_qry_test(resp, err, "ChildJoins - Put items"); err != nil {
+			t.Fatal(err)
+		}
+
+		type orderWithItems struct {
+			Id       string           `json:"id"`
+			ItemCnt  int              `json:"itemCnt"`
+			Items    []data.OrderItem `json:"items"`
+			AllItems []data.OrderItem `json:"allItems"`
+		}
+		// by key prefix: items with qty > 1, largest first, limit 1; count used in find and sort
+		// by index: all items in index key order
+		resp, err = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{
+			BktName: orderBkt,
+			ChildJoinsBeforeFind: []bobb.ChildJoin{{
+				ChildBkt:  itemBkt,
+				KeyFld:    "id",
+				Separator: "_",
+				ToFld:     "items",
+				CountFld:  "itemCnt",
+				Criteria:  []bobb.FindGroup{bo.Find(nil, "qty", bobb.FindGreaterThan, 1)},
+				SortKeys:  []bobb.SortKey{{Fld: "qty", Dir: bobb.SortDescInt}},
+				Limit:     1,
+				Fields:    []string{"productId", "qty"},
+			}},
+			ChildJoinsAfterFind: []bobb.ChildJoin{{ChildBkt: itemBkt, IndexBkt: itemOrderIdx, KeyFld: "id", ToFld: "allItems"}},
+			Criteria:            []bobb.FindGroup{bo.Find(nil, "itemCnt", bobb.FindGreaterThan, 0)},
+			SortKeys:            []bobb.SortKey{{Fld: "itemCnt", Dir: bobb.SortAscInt}},
+			Explain:             true,
+		})
+		if err := checkResp_qry_test(resp, err, "ChildJoins"); err != nil {
+			t.Fatal(err)
+		}
+		results := make([]orderWithItems, len(resp.Recs))
+		for i, rec := range resp.Recs {
+			json.Unmarshal(rec, &results[i])
+		}
+		got := make([]string, len(results))
+		for i, r := range results {
+			got[i] = fmt.Sprintf("%s %d %v", r.Id, r.ItemCnt, r.Items)
+			for _, item := range r.AllItems {
+				got[i] += " " + item.Id
+			}
+		}
+		expected := []string{"ord2 1 [{  0 p1 2}] ord2_1", "ord1 2 [{  0 p1 5}] ord1_1 ord1_2 ord1_3"}
+		if !slices.Equal(got, expected) {
+			t.Errorf("ChildJoins: expected %q, got %q", expected, got)
+		}
+		if resp.Stats == nil || resp.Stats.JoinLookups != 4+4 {
+			t.Errorf("ChildJoins: expected 8 join lookups (4 by prefix, 4 by index), got %+v", resp.Stats)
+		}
+
+		// KeyFld not found → error, UseDefault → empty array and 0 count
+		childJoin := bobb.ChildJoin{ChildBkt: itemBkt, KeyFld: "custId", Separator: "_", ToFld: "items", CountFld: "itemCnt"}
+		resp, _ = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{BktName: orderBkt, ChildJoinsAfterFind: []bobb.ChildJoin{childJoin}, ErrLimit: -1})
+		if resp.Status != bobb.StatusWarning || len(resp.Errs) != 3 || resp.Errs[0].ErrCode != bobb.ErrJoinFld {
+			t.Errorf("ChildJoins missing KeyFld: expected warning with 3 %s errs, got %s %v", bobb.ErrJoinFld, resp.Status, resp.Errs)
+		}
+		childJoin.UseDefault = true
+		resp, err = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{BktName: orderBkt, ChildJoinsAfterFind: []bobb.ChildJoin{childJoin}})
+		if err := checkResp_qry_test(resp, err, "ChildJoins UseDefault"); err != nil {
+			t.Fatal(err)
+		}
+		if string(resp.Recs[0]) != `{"id":"ord1","orderDate":"","customerId":"","items":[],"itemCnt":0}` {
+			t.Errorf("ChildJoins UseDefault: unexpected rec %s", resp.Recs[0])
+		}
+
+		// invalid child join → validation failure
+		resp, _ = bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{BktName: orderBkt, ChildJoinsAfterFind: []bobb.ChildJoin{{ChildBkt: itemBkt, KeyFld: "id"}}})
+		if resp.Status != bobb.StatusFail {
+			t.Errorf("ChildJoins no ToFld or CountFld: expected StatusFail, got %s", resp.Status)
+		}
+	})
+
+	// -----------------------------------------------------------------------
+	t.Run("Explain", func(t *testing.T) {
+		// Qry: join before find, criteria, top sort; results must match the same request without Explain
+		qry := bobb.QryRequest{
+			BktName:         qryJoinBkt,
+			JoinsBeforeFind: []bobb.Join{{JoinBkt: qryTestBkt, JoinFld: "locationId", FromFld: "city", ToFld: "location_city"}},
+			Criteria:        []bobb.FindGroup{bo.Find(nil, "location_city", bobb.FindMatches, "Austin")},
+			SortKeys:        bo.Sort(nil, "description", bobb.SortDescStr),
+			Top:             1,
+		}
+		expected, err := bo.Run(httpClient, bobb.OpQry, qry)
+		if err := checkResp_qry_test(expected, err, "Explain Qry - no explain"); err != nil {
+			t.Fatal(err)
+		}
+		if expected.Stats != nil {
+			t.Error("Explain Qry: expected nil Stats when Explain not set")
+		}
+		qry.Explain = true
+		resp, err := bo.Run(httpClient, bobb.OpQry, qry)
+		if err := checkResp_qry_test(resp, err, "Explain Qry"); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.EqualFunc(resp.Recs, expected.Recs, slices.Equal) {
+			t.Error("Explain Qry: results changed by Explain")
+		}
+		stats := resp.Stats
+		if stats == nil {
+			t.Fatal("Explain Qry: Stats not returned")
+		}
+		if stats.KeysScanned != 3 || stats.RecsParsed != 3 || stats.RecsMatched != 2 || stats.JoinLookups != 3 || stats.ErrsSkipped != 0 {
+			t.Errorf("Explain Qry: unexpected counts %+v", *stats)
+		}
+		expectedPlan := "bkt qry_join_test, all keys; 1 joins before find; find 1 groups; sort 1 keys, top 1 heap"
+		if stats.Plan != expectedPlan {
+			t.Errorf("Explain Qry: expected plan %q, got %q", expectedPlan, stats.Plan)
+		}
+		var phases []string
+		for _, phase := range stats.Phases {
+			phases = append(phases, phase.Phase)
+		}
+		if !slices.Equal(phases, []string{"validate", "scan", "parse", "join", "find", "sortkey", "sort", "merge"}) {
+			t.Errorf("Explain Qry: unexpected phases %v", phases)
+		}
+
+		// GetAll through index: each index entry is a data bkt lookup
+		resp, err = bo.Run(httpClient, bobb.OpGetAll, bobb.GetAllRequest{
+			BktName:  qryTestBkt,
+			IndexBkt: qryZipIndex,
+			StartKey: "78",
+			EndKey:   "78",
+			Explain:  true,
+		})
+		if err := checkResp_qry_test(resp, err, "Explain GetAll"); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Stats == nil || resp.Stats.KeysScanned != 2 || resp.Stats.IndexLookups != 2 || resp.Stats.RecsMatched != 2 {
+			t.Errorf("Explain GetAll: unexpected stats %+v", resp.Stats)
+		} else if resp.Stats.Plan != `index qry_test_zip_index > bkt qry_test, key prefix "78"` {
+			t.Errorf("Explain GetAll: unexpected plan %q", resp.Stats.Plan)
+		}
+
+		// SearchKeys: all keys scanned, keys containing "0" followed by "1" → 001, 010
+		resp, err = bo.Run(httpClient, bobb.OpSearchKeys, bobb.SearchKeysRequest{
+			BktName:     qryTestBkt,
+			SearchValue: "01",
+			Explain:     true,
+		})
+		if err := checkResp_qry_test(resp, err, "Explain SearchKeys"); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Stats == nil || resp.Stats.KeysScanned != len(qryLocs) || resp.Stats.RecsMatched != 2 {
+			t.Errorf("Explain SearchKeys: unexpected stats %+v", resp.Stats)
+		}
+	})
+
 	// -----------------------------------------------------------------------
 	t.Run("IndexBkt", func(t *testing.T) {
 		// Zip index keys: "78701|NNNN" (001) and "78702|NNNN" (005).
@@ -721,6 +1853,348 @@ func TestQry(t *testing.T) {
 		}
 	})
 
+	// -----------------------------------------------------------------------
+	t.Run("GetByIndex", func(t *testing.T) {
+		// Exact zip, index key encoding (suffix) handled by server → 001
+		resp, err := bo.Run(httpClient, bobb.OpGetByIndex, bobb.GetByIndexRequest{
+			IndexBkt:   qryZipIndex,
+			Conditions: []bobb.IndexCondition{{ValStr: "78701"}},
+		})
+		if err := checkResp_qry_test(resp, err, "GetByIndex equals"); err != nil {
+			t.Error(err)
+		}
+		results := bo.JsonToSlice(resp.Recs, data.Location{})
+		if got := ids(results); !slices.Equal(got, []string{"001"}) {
+			t.Errorf("GetByIndex equals: expected [001], got %v", got)
+		}
+
+		// Prefix "787" → 001, 005
+		resp, err = bo.Run(httpClient, bobb.OpGetByIndex, bobb.GetByIndexRequest{
+			IndexBkt:   qryZipIndex,
+			Conditions: []bobb.IndexCondition{{Op: bobb.IndexPrefix, ValStr: "787"}},
+		})
+		if err := checkResp_qry_test(resp, err, "GetByIndex prefix"); err != nil {
+			t.Error(err)
+		}
+		results = bo.JsonToSlice(resp.Recs, data.Location{})
+		if got := ids(results); !slices.Equal(got, []string{"001", "005"}) {
+			t.Errorf("GetByIndex prefix: expected [001 005], got %v", got)
+		}
+
+		// Range 60000-78701 inclusive, zip order → 003 (60601), 008 (77001), 001 (78701)
+		resp, err = bo.Run(httpClient, bobb.OpGetByIndex, bobb.GetByIndexRequest{
+			IndexBkt:   qryZipIndex,
+			Conditions: []bobb.IndexCondition{{Op: bobb.IndexRange, ValStr: "60000", ToStr: "78701"}},
+		})
+		if err := checkResp_qry_test(resp, err, "GetByIndex range"); err != nil {
+			t.Error(err)
+		}
+		results = bo.JsonToSlice(resp.Recs, data.Location{})
+		if got := ids(results); !slices.Equal(got, []string{"003", "008", "001"}) {
+			t.Errorf("GetByIndex range: expected [003 008 001], got %v", got)
+		}
+
+		// More conditions than KeyFlds → StatusFail
+		resp, _ = bo.Run(httpClient, bobb.OpGetByIndex, bobb.GetByIndexRequest{
+			IndexBkt:   qryZipIndex,
+			Conditions: []bobb.IndexCondition{{ValStr: "78701"}, {ValStr: "x"}},
+		})
+		if resp.Status != bobb.StatusFail {
+			t.Errorf("GetByIndex too many conditions: expected StatusFail, got %s", resp.Status)
+		}
+
+		// Prefix on padded 1st fld of an index with FldSeparator, keys "Austin      |78701|0001" → 001, 005
+		const cityZipBkt, cityZipIndex = "qry_test_cityzip", "qry_test_cityzip_index"
+		cleanup := func() {
+			for _, bkt := range []string{cityZipBkt, cityZipIndex, cityZipIndex + "_inverted"} {
+				bo.DeleteBkt(httpClient, bkt)
+			}
+		}
+		cleanup()
+		defer cleanup()
+		resp, err = bo.Run(httpClient, bobb.OpIndexSetting, bobb.IndexSettingRequest{IndexSettings: []bobb.IndexSetting{{
+			DataBkt:        cityZipBkt,
+			IndexBkt:       cityZipIndex,
+			FldSeparator:   "|",
+			KeySuffixWidth: 4,
+			KeyFlds: []bobb.FldFormat{
+				{FldName: "city", FldType: bobb.FldTypeStr, Length: 12, StrOption: bobb.StrAsIs, UseDefault: bobb.DefaultAlways},
+				{FldName: "zip", FldType: bobb.FldTypeStr, Length: 5, StrOption: bobb.StrAsIs, UseDefault: bobb.DefaultAlways},
+			},
+		}}})
+		if err := checkResp_qry_test(resp, err, "GetByIndex separator - IndexSetting"); err != nil {
+			t.Fatal(err)
+		}
+		resp, err = bo.Put(httpClient, cityZipBkt, bo.SliceToJson(qryLocs), nil)
+		if err := checkResp_qry_test(resp, err, "GetByIndex separator - Put"); err != nil {
+			t.Fatal(err)
+		}
+		for _, conditions := range [][]bobb.IndexCondition{
+			{{Op: bobb.IndexPrefix, ValStr: "Aus"}},
+			{{ValStr: "Austin"}, {Op: bobb.IndexPrefix, ValStr: "787"}},
+		} {
+			resp, err = bo.Run(httpClient, bobb.OpGetByIndex, bobb.GetByIndexRequest{IndexBkt: cityZipIndex, Conditions: conditions})
+			if err := checkResp_qry_test(resp, err, "GetByIndex separator prefix"); err != nil {
+				t.Error(err)
+			}
+			results = bo.JsonToSlice(resp.Recs, data.Location{})
+			if got := ids(results); !slices.Equal(got, []string{"001", "005"}) {
+				t.Errorf("GetByIndex separator prefix %+v: expected [001 005], got %v", conditions, got)
+			}
+		}
+	})
+
+	// -----------------------------------------------------------------------
+	t.Run("WithMeta", func(t *testing.T) {
+		checkMeta := func(name string, resp *bobb.Response, expectedKeys []string, indexKeys bool) {
+			t.Helper()
+			if len(resp.Meta) != len(resp.Recs) {
+				t.Errorf("%s: expected %d meta, got %d", name, len(resp.Recs), len(resp.Meta))
+				return
+			}
+			var keys []string
+			for _, meta := range resp.Meta {
+				keys = append(keys, meta.Key)
+				if meta.Size == 0 {
+					t.Errorf("%s: expected size of rec %s, got %d", name, meta.Key, meta.Size)
+				}
+				if indexKeys != (meta.IndexKey != "") {
+					t.Errorf("%s: unexpected index key %q for rec %s", name, meta.IndexKey, meta.Key)
+				}
+			}
+			if !slices.Equal(keys, expectedKeys) {
+				t.Errorf("%s: expected keys %v, got %v", name, expectedKeys, keys)
+			}
+		}
+
+		// sorted qry through index, meta follows recs through sort and projection
+		resp, err := bo.Run(httpClient, bobb.OpQry, bobb.QryRequest{
+			BktName:  qryTestBkt,
+			IndexBkt: qryZipIndex,
+			StartKey: "78",
+			EndKey:   "79",
+			SortKeys: []bobb.SortKey{{Fld: "id", Dir: bobb.SortDescStr}},
+			Fields:   []string{"city"},
+			WithMeta: true,
+		})
+		if err := checkResp_qry_test(resp, err, "WithMeta Qry"); err != nil {
+			t.Fatal(err)
+		}
+		checkMeta("WithMeta Qry", resp, []string{"005", "001"}, true)
+		if !strings.HasPrefix(resp.Meta[0].IndexKey, "78702") || resp.Meta[0].Size <= len(resp.Recs[0]) {
+			t.Errorf("WithMeta Qry: expected index key 78702... and stored size > projected size, got %+v", resp.Meta[0])
+		}
+
+		resp, err = bo.Run(httpClient, bobb.OpGetAll, bobb.GetAllRequest{BktName: qryTestBkt, StartKey: "002", EndKey: "003", WithMeta: true})
+		if err := checkResp_qry_test(resp, err, "WithMeta GetAll"); err != nil {
+			t.Fatal(err)
+		}
+		checkMeta("WithMeta GetAll", resp, []string{"002", "003"}, false)
+		if resp.Meta[0].Size != len(resp.Recs[0]) {
+			t.Errorf("WithMeta GetAll: expected size %d, got %d", len(resp.Recs[0]), resp.Meta[0].Size)
+		}
+
+		resp, _ = bo.Run(httpClient, bobb.OpGet, bobb.GetRequest{BktName: qryTestBkt, Keys: []string{"004", "nokey", "001"}, ErrLimit: 1, WithMeta: true})
+		checkMeta("WithMeta Get", resp, []string{"004", "001"}, false)
+
+		resp, _ = bo.Run(httpClient, bobb.OpSearchKeys, bobb.SearchKeysRequest{BktName: qryTestBkt, SearchValue: "1", WithMeta: true})
+		checkMeta("WithMeta SearchKeys", resp, []string{"001", "010"}, false)
+
+		resp, _ = bo.Run(httpClient, bobb.OpGetAll, bobb.GetAllRequest{BktName: qryTestBkt, Limit: 1})
+		if resp.Meta != nil {
+			t.Errorf("WithMeta false: expected no meta, got %v", resp.Meta)
+		}
+	})
+
+	// -----------------------------------------------------------------------
+	t.Run("RawRecs", func(t *testing.T) {
+		requests := []struct {
+			op  string
+			req any
+		}{
+			{bobb.OpGetAll, bobb.GetAllRequest{BktName: qryTestBkt, Fields: []string{"id", "city"}}},
+			{bobb.OpGetAllKeys, bobb.GetAllKeysRequest{BktName: qryTestBkt, Limit: 3}},
+			{bobb.OpGetOne, bobb.GetOneRequest{BktName: qryTestBkt, Key: "004"}},
+			{bobb.OpGet, bobb.GetRequest{BktName: qryTestBkt, Keys: []string{"001", "nokey"}, ErrLimit: 1}},
+			{bobb.OpQryStr, bobb.QryStrRequest{Qry: "from qry_test where st = 'TX'", Compile: true}},
+		}
+		defer func() { bo.RawRecs = false }()
+		for _, r := range requests {
+			bo.RawRecs = false
+			expected, err := bo.Run(httpClient, r.op, r.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bo.RawRecs = true
+			got, err := bo.Run(httpClient, r.op, r.req)
+			if err != nil {
+				t.Fatalf("RawRecs %s: %v", r.op, err)
+			}
+			if !slices.EqualFunc(got.Recs, expected.Recs, func(a, b []byte) bool { return string(a) == string(b) }) ||
+				string(got.Rec) != string(expected.Rec) || got.Status != expected.Status || len(got.Errs) != len(expected.Errs) {
+				t.Errorf("RawRecs %s: raw response differs\n got %s %q %q\n expected %s %q %q",
+					r.op, got.Status, got.Recs, got.Rec, expected.Status, expected.Recs, expected.Rec)
+			}
+		}
+
+		// recs embedded as json in the response body, requested by url param
+		body := strings.NewReader(`{"BktName":"qry_test","Keys":["001"]}`)
+		httpResp, err := httpClient.Post(bo.BaseURL+bobb.OpGet+"?"+bobb.RecEncodingParam+"="+bobb.RecEncodingRaw, "application/json", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer httpResp.Body.Close()
+		var raw struct{ Recs []data.Location }
+		if err := json.NewDecoder(httpResp.Body).Decode(&raw); err != nil || len(raw.Recs) != 1 || raw.Recs[0].Id != "001" {
+			t.Errorf("RawRecs url param: expected rec 001 as json, got %v %v", raw.Recs, err)
+		}
+		if httpResp.Header.Get(bobb.RecEncodingHeader) != bobb.RecEncodingRaw {
+			t.Errorf("RawRecs url param: expected %s response header", bobb.RecEncodingHeader)
+		}
+
+		// keys that look like json are still returned as strings
+		const keysBkt = "qry_test_rawkeys"
+		cleanup := func() {
+			bo.Run(httpClient, bobb.OpBktAdmin, bobb.BktAdminRequest{Operation: bobb.AdminDropBkt, BktName: keysBkt})
+		}
+		cleanup()
+		defer cleanup()
+		keys := []string{"[2024", "b", "{a"}
+		var recs [][]byte
+		for _, key := range keys {
+			recs = append(recs, []byte(fmt.Sprintf(`{"id":%q}`, key)))
+		}
+		resp, err := bo.Put(httpClient, keysBkt, recs, nil)
+		if err := checkResp_qry_test(resp, err, "RawRecs keys - Put"); err != nil {
+			t.Fatal(err)
+		}
+		bo.RawRecs = true
+		resp, err = bo.Run(httpClient, bobb.OpGetAllKeys, bobb.GetAllKeysRequest{BktName: keysBkt})
+		if err != nil || !slices.EqualFunc(resp.Recs, keys, func(a []byte, b string) bool { return string(a) == b }) {
+			t.Errorf("RawRecs keys: expected %q, got %q %v", keys, resp.Recs, err)
+		}
+
+		// index bkt values are data keys, not json, read by GetAll
+		const indexBkt = "qry_test_rawkeys_index"
+		bo.DeleteBkt(httpClient, indexBkt)
+		defer bo.DeleteBkt(httpClient, indexBkt)
+		var indexes []bobb.IndexKeyVal
+		for i, key := range keys {
+			indexes = append(indexes, bobb.IndexKeyVal{Key: fmt.Sprintf("i%d", i), Val: key})
+		}
+		resp, err = bo.Run(httpClient, bobb.OpPutIndex, bobb.PutIndexRequest{BktName: indexBkt, Indexes: indexes})
+		if err := checkResp_qry_test(resp, err, "RawRecs index - PutIndex"); err != nil {
+			t.Fatal(err)
+		}
+		resp, err = bo.Run(httpClient, bobb.OpGetAll, bobb.GetAllRequest{BktName: indexBkt})
+		if err != nil || !slices.EqualFunc(resp.Recs, keys, func(a []byte, b string) bool { return string(a) == b }) {
+			t.Errorf("RawRecs index bkt: expected %q, got %q %v", keys, resp.Recs, err)
+		}
+	})
+
+	// -----------------------------------------------------------------------
+	t.Run("ListKeys", func(t *testing.T) {
+		const listBkt = "qry_test_listkeys"
+		cleanup := func() {
+			bo.Run(httpClient, bobb.OpBktAdmin, bobb.BktAdminRequest{Operation: bobb.AdminDropBkt, BktName: listBkt})
+		}
+		cleanup()
+		defer cleanup()
+		var recs [][]byte
+		for _, key := range []string{"acme|2024-01|001", "acme|2024-01|002", "acme|2024-02|001", "acme|notes", "bolt|2024-01|001", "zeta"} {
+			recs = append(recs, []byte(fmt.Sprintf(`{"id":%q}`, key)))
+		}
+		resp, err := bo.Put(httpClient, listBkt, recs, nil)
+		if err := checkResp_qry_test(resp, err, "ListKeys - Put"); err != nil {
+			t.Fatal(err)
+		}
+		list := func(req bobb.ListKeysRequest) (string, string) {
+			t.Helper()
+			req.BktName = listBkt
+			resp, err := bo.Run(httpClient, bobb.OpListKeys, req)
+			if err := checkResp_qry_test(resp, err, "ListKeys"); err != nil {
+				t.Fatal(err)
+			}
+			var listing bobb.KeyListing
+			json.Unmarshal(resp.Rec, &listing)
+			var entries []string
+			for _, p := range listing.Prefixes {
+				entries = append(entries, fmt.Sprintf("%s(%d)", p.Prefix, p.Count))
+			}
+			return strings.Join(append(entries, listing.Keys...), " "), resp.NextKey
+		}
+		tests := []struct {
+			req      bobb.ListKeysRequest
+			expected string
+		}{
+			{bobb.ListKeysRequest{Delimiter: "|"}, "acme|(4) bolt|(1) zeta"},
+			{bobb.ListKeysRequest{Prefix: "acme|", Delimiter: "|"}, "acme|2024-01|(2) acme|2024-02|(1) acme|notes"},
+			{bobb.ListKeysRequest{Prefix: "acme|", Delimiter: "|", NoCounts: true}, "acme|2024-01|(0) acme|2024-02|(0) acme|notes"},
+			{bobb.ListKeysRequest{Prefix: "acme|2024-01|"}, "acme|2024-01|001 acme|2024-01|002"},
+		}
+		for _, test := range tests {
+			if got, _ := list(test.req); got != test.expected {
+				t.Errorf("ListKeys %+v: expected %q, got %q", test.req, test.expected, got)
+			}
+		}
+
+		// pages of 1 entry, cursor continues after the last prefix
+		req := bobb.ListKeysRequest{Delimiter: "|", Limit: 1, NoCounts: true}
+		var pages []string
+		for range 5 {
+			got, next := list(req)
+			pages = append(pages, got)
+			if next == "" {
+				break
+			}
+			req.Cursor = next
+		}
+		if expected := []string{"acme|(0)", "bolt|(0)", "zeta"}; !slices.Equal(pages, expected) {
+			t.Errorf("ListKeys pages: expected %q, got %q", expected, pages)
+		}
+	})
+
+	// -----------------------------------------------------------------------
+	t.Run("MultiGet", func(t *testing.T) {
+		items := []bobb.MultiGetItem{
+			{BktName: qryTestBkt, Key: "002"},
+			{BktName: qryJoinBkt, Key: "req001"},
+			{BktName: qryTestBkt, Key: "nokey"},
+			{BktName: "qry_test_nosuchbkt", Key: "001"},
+		}
+		defer func() { bo.RawRecs = false }()
+		for _, raw := range []bool{false, true} {
+			bo.RawRecs = raw
+			name := fmt.Sprintf("MultiGet raw %v", raw)
+			resp, err := bo.Run(httpClient, bobb.OpMultiGet, bobb.MultiGetRequest{Items: items, Fields: []string{"id"}})
+			if err := checkResp_qry_test(resp, err, name); err != nil {
+				t.Fatal(err)
+			}
+			var results []bobb.MultiGetResult
+			json.Unmarshal(resp.Rec, &results)
+			var got []string
+			for i, result := range results {
+				got = append(got, fmt.Sprintf("%s %v %s", result.Status, result.Found, resp.Recs[i]))
+			}
+			expected := []string{`ok true {"id":"002"}`, `ok true {"id":"req001"}`, "notfound false ", "bktnotfound false "}
+			if !slices.Equal(got, expected) || resp.GetCnt != 2 || resp.Recs[2] != nil {
+				t.Errorf("%s: expected %q, got %q, GetCnt %d", name, expected, got, resp.GetCnt)
+			}
+		}
+
+		// exists only: no recs, sizes of stored recs
+		resp, err := bo.Run(httpClient, bobb.OpMultiGet, bobb.MultiGetRequest{Items: items, ExistsOnly: true})
+		if err := checkResp_qry_test(resp, err, "MultiGet ExistsOnly"); err != nil {
+			t.Fatal(err)
+		}
+		var results []bobb.MultiGetResult
+		json.Unmarshal(resp.Rec, &results)
+		one, _ := bo.Run(httpClient, bobb.OpGetOne, bobb.GetOneRequest{BktName: qryTestBkt, Key: "002"})
+		if resp.Recs != nil || len(results) != 4 || !results[0].Found || results[0].Size != len(one.Rec) || results[2].Found || results[2].Size != 0 {
+			t.Errorf("MultiGet ExistsOnly: unexpected results %+v, %d recs", results, len(resp.Recs))
+		}
+	})
+
 	// -----------------------------------------------------------------------
 	t.Run("ErrorHandling", func(t *testing.T) {
 		// Missing bucket → StatusFail
@@ -0,0 +1,132 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jayposs/bobb"
+	bo "github.com/jayposs/bobb/client"
+	data "github.com/jayposs/bobb/datatypes"
+)
+
+const snapshotTestBkt = "snapshot_test"
+
+// TestSnapshot covers SnapshotRequest open, list, close and expiry.
+// Verifies Get, GetAll and Qry in a snapshot do not see writes made after it was opened.
+func TestSnapshot(t *testing.T) {
+	bo.BaseURL = "http://localhost:50555/"
+	bo.Debug = false
+
+	httpClient := &http.Client{}
+
+	cleanup := func() {
+		bo.Run(httpClient, bobb.OpBktAdmin, bobb.BktAdminRequest{BktName: snapshotTestBkt, Operation: bobb.AdminDropBkt})
+	}
+	cleanup()
+	defer cleanup()
+
+	put := func(recs []data.Location) {
+		t.Helper()
+		resp, err := bo.Put(httpClient, snapshotTestBkt, bo.SliceToJson(recs), nil)
+		if err := checkResp(resp, err, "Snapshot - Put"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	put([]data.Location{{Id: "s1", City: "Memphis"}, {Id: "s2", City: "Austin"}})
+
+	open := func(ttlSecs int) bobb.SnapshotInfo {
+		t.Helper()
+		resp, err := bo.Run(httpClient, bobb.OpSnapshot, bobb.SnapshotRequest{Operation: bobb.SnapshotOpen, TTLSecs: ttlSecs})
+		if err := checkResp(resp, err, "Snapshot - open"); err != nil {
+			t.Fatal(err)
+		}
+		var info bobb.SnapshotInfo
+		if err := json.Unmarshal(resp.Rec, &info); err != nil || info.Id == "" {
+			t.Fatalf("Snapshot - open: expected SnapshotInfo, got %s %v", resp.Rec, err)
+		}
+		return info
+	}
+	snap := open(0)
+	if d := snap.Expires.Sub(snap.Opened); d != bobb.DefaultSnapshotSecs*time.Second {
+		t.Errorf("Snapshot - open: expected default TTL, got %v", d)
+	}
+
+	// writes after open: s2 changed, s3 added
+	put([]data.Location{{Id: "s2", City: "Denver"}, {Id: "s3", City: "Boston"}})
+
+	cities := func(resp *bobb.Response, err error, desc string) []string {
+		t.Helper()
+		if err := checkResp(resp, err, desc); err != nil {
+			t.Fatal(err)
+		}
+		var result []string
+		for _, loc := range bo.JsonToSlice(resp.Recs, data.Location{}) {
+			result = append(result, loc.City)
+		}
+		return result
+	}
+	tests := []struct {
+		desc     string
+		op       string
+		req      func(snapshot string) any
+		expected []string
+		current  []string
+	}{
+		{"GetAll", bobb.OpGetAll, func(s string) any {
+			return bobb.GetAllRequest{BktName: snapshotTestBkt, Snapshot: s}
+		}, []string{"Memphis", "Austin"}, []string{"Memphis", "Denver", "Boston"}},
+		{"Get", bobb.OpGet, func(s string) any {
+			return bobb.GetRequest{BktName: snapshotTestBkt, Keys: []string{"s2"}, Snapshot: s}
+		}, []string{"Austin"}, []string{"Denver"}},
+		{"Qry", bobb.OpQry, func(s string) any {
+			return bobb.QryRequest{BktName: snapshotTestBkt, SortKeys: []bobb.SortKey{{Fld: "city", Dir: bobb.SortAscStr}}, Snapshot: s}
+		}, []string{"Austin", "Memphis"}, []string{"Boston", "Denver", "Memphis"}},
+	}
+	for _, test := range tests {
+		resp, err := bo.Run(httpClient, test.op, test.req(snap.Id))
+		if got := cities(resp, err, "Snapshot "+test.desc); !slices.Equal(got, test.expected) {
+			t.Errorf("Snapshot %s: expected %v, got %v", test.desc, test.expected, got)
+		}
+		resp, err = bo.Run(httpClient, test.op, test.req(""))
+		if got := cities(resp, err, "Snapshot "+test.desc+" current"); !slices.Equal(got, test.current) {
+			t.Errorf("Snapshot %s current: expected %v, got %v", test.desc, test.current, got)
+		}
+	}
+
+	resp, err := bo.Run(httpClient, bobb.OpSnapshot, bobb.SnapshotRequest{Operation: bobb.SnapshotList})
+	if err := checkResp(resp, err, "Snapshot - list"); err != nil {
+		t.Fatal(err)
+	}
+	var list []bobb.SnapshotInfo
+	json.Unmarshal(resp.Rec, &list)
+	if !slices.ContainsFunc(list, func(info bobb.SnapshotInfo) bool { return info.Id == snap.Id }) {
+		t.Errorf("Snapshot - list: expected %s in %+v", snap.Id, list)
+	}
+
+	// closed or expired snapshot cannot be used
+	resp, err = bo.Run(httpClient, bobb.OpSnapshot, bobb.SnapshotRequest{Operation: bobb.SnapshotClose, Id: snap.Id})
+	if err := checkResp(resp, err, "Snapshot - close"); err != nil {
+		t.Fatal(err)
+	}
+	expiring := open(1)
+	time.Sleep(1500 * time.Millisecond)
+	for _, id := range []string{snap.Id, expiring.Id, "nosuchid"} {
+		resp, _ = bo.Run(httpClient, bobb.OpGetAll, bobb.GetAllRequest{BktName: snapshotTestBkt, Snapshot: id})
+		if resp.Status != bobb.StatusFail {
+			t.Errorf("Snapshot %s closed: expected StatusFail, got %s", id, resp.Status)
+		}
+	}
+	resp, _ = bo.Run(httpClient, bobb.OpSnapshot, bobb.SnapshotRequest{Operation: bobb.SnapshotClose, Id: snap.Id})
+	if resp.Status != bobb.StatusFail {
+		t.Errorf("Snapshot - close twice: expected StatusFail, got %s", resp.Status)
+	}
+
+	// limits
+	resp, _ = bo.Run(httpClient, bobb.OpSnapshot, bobb.SnapshotRequest{Operation: bobb.SnapshotOpen, TTLSecs: 100000})
+	if resp.Status != bobb.StatusFail {
+		t.Errorf("Snapshot - TTL over max: expected StatusFail, got %s", resp.Status)
+	}
+}
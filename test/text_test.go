@@ -0,0 +1,143 @@
+package test
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/jayposs/bobb"
+	bo "github.com/jayposs/bobb/client"
+	data "github.com/jayposs/bobb/datatypes"
+)
+
+const (
+	textTestBkt   = "text_test"
+	textTestIndex = "text_test_description_index"
+)
+
+// reqIds extracts Id fields from a slice of Request records for order assertions.
+func reqIds(recs []data.Request) []string {
+	result := make([]string, len(recs))
+	for i, rec := range recs {
+		result[i] = rec.Id
+	}
+	return result
+}
+
+// TestTextSearch covers text index maintenance by Put/Delete and TextSearchRequest term, prefix and phrase matching.
+func TestTextSearch(t *testing.T) {
+	bo.BaseURL = "http://localhost:50555/"
+	bo.Debug = false
+
+	httpClient := &http.Client{}
+
+	cleanup := func() {
+		bo.Run(httpClient, bobb.OpBktAdmin, bobb.BktAdminRequest{BktName: textTestBkt, Operation: bobb.AdminDropBkt})
+	}
+	cleanup()
+	defer cleanup()
+
+	setting := bobb.IndexSetting{
+		DataBkt:   textTestBkt,
+		IndexBkt:  textTestIndex,
+		IndexType: bobb.IndexTypeText,
+		TextFlds:  []string{"description"},
+	}
+	resp, err := bo.Run(httpClient, bobb.OpIndexSetting, bobb.IndexSettingRequest{IndexSettings: []bobb.IndexSetting{setting}})
+	if err := checkResp(resp, err, "TextSearch - IndexSettingRequest"); err != nil {
+		t.Fatal(err)
+	}
+	recs := []data.Request{
+		{Id: "r1", Description: "Routine inspection, roof."},
+		{Id: "r2", Description: "Urgent repair: roof leaking. Repairs needed!"},
+		{Id: "r3", Description: "Repair urgent? No, inspected roof only."},
+		{Id: "r4", Description: "Window cleaning"},
+		{Id: "r5", Description: "Public hearings scheduled"},
+	}
+	resp, err = bo.Put(httpClient, textTestBkt, bo.SliceToJson(recs), nil)
+	if err := checkResp(resp, err, "TextSearch - Put"); err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(desc string, req bobb.TextSearchRequest) []string {
+		t.Helper()
+		req.IndexBkt = textTestIndex
+		resp, err := bo.Run(httpClient, bobb.OpTextSearch, req)
+		if err := checkResp(resp, err, desc); err != nil {
+			t.Fatal(err)
+		}
+		return reqIds(bo.JsonToSlice(resp.Recs, data.Request{}))
+	}
+
+	// AND, punctuation ignored, "repairs" stemmed: r2 ranked first (repair twice)
+	if got := search("AllTerms", bobb.TextSearchRequest{AllTerms: []string{"roof", "repair"}}); !slices.Equal(got, []string{"r2", "r3"}) {
+		t.Errorf("TextSearch AllTerms: expected [r2 r3], got %v", got)
+	}
+	// OR
+	if got := search("AnyTerms", bobb.TextSearchRequest{AnyTerms: []string{"window", "routine"}}); len(got) != 2 || !slices.Contains(got, "r1") || !slices.Contains(got, "r4") {
+		t.Errorf("TextSearch AnyTerms: expected r1 and r4, got %v", got)
+	}
+	// prefix term, inspection and inspected (stemmed to inspect)
+	if got := search("prefix", bobb.TextSearchRequest{AllTerms: []string{"insp*"}}); len(got) != 2 || !slices.Contains(got, "r1") || !slices.Contains(got, "r3") {
+		t.Errorf("TextSearch prefix: expected r1 and r3, got %v", got)
+	}
+	// phrase, words in order
+	if got := search("phrase", bobb.TextSearchRequest{Phrases: []string{"urgent repair"}}); !slices.Equal(got, []string{"r2"}) {
+		t.Errorf("TextSearch phrase: expected [r2], got %v", got)
+	}
+	// phrase word stemmed once, hearings > hearing (stemming hearing again gives hear)
+	if got := search("phrase stem", bobb.TextSearchRequest{Phrases: []string{"public hearings"}}); !slices.Equal(got, []string{"r5"}) {
+		t.Errorf("TextSearch phrase stem: expected [r5], got %v", got)
+	}
+
+	// update r2 and delete r3, index entries follow
+	resp, err = bo.PutOne(httpClient, textTestBkt, data.Request{Id: "r2", Description: "Window repair"}, nil)
+	if err := checkResp(resp, err, "TextSearch - Put update"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = bo.Run(httpClient, bobb.OpDelete, bobb.DeleteRequest{BktName: textTestBkt, Keys: []string{"r3"}})
+	if err := checkResp(resp, err, "TextSearch - Delete"); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("after update", bobb.TextSearchRequest{AllTerms: []string{"repair"}}); !slices.Equal(got, []string{"r2"}) {
+		t.Errorf("TextSearch after update: expected [r2], got %v", got)
+	}
+	if got := search("limit", bobb.TextSearchRequest{AnyTerms: []string{"window", "roof"}, Limit: 1}); len(got) != 1 {
+		t.Errorf("TextSearch limit: expected 1 rec, got %v", got)
+	}
+
+	// text index values are word positions, not data keys, key index requests fail
+	for _, r := range []struct {
+		op  string
+		req any
+	}{
+		{bobb.OpGetAll, bobb.GetAllRequest{BktName: textTestBkt, IndexBkt: textTestIndex}},
+		{bobb.OpGetByIndex, bobb.GetByIndexRequest{IndexBkt: textTestIndex}},
+		{bobb.OpQry, bobb.QryRequest{BktName: textTestBkt, IndexBkt: textTestIndex}},
+		{bobb.OpVerifyIndex, bobb.VerifyIndexRequest{DataBkt: textTestBkt, IndexBkt: textTestIndex}},
+	} {
+		resp, _ = bo.Run(httpClient, r.op, r.req)
+		if resp.Status != bobb.StatusFail {
+			t.Errorf("TextSearch %s on text index: expected StatusFail, got %s", r.op, resp.Status)
+		}
+	}
+
+	// text index defined after recs are loaded is built by IndexRequest
+	// public (1 of 4 recs) ranks above window (2 of 4 recs), idf uses the indexed rec count
+	setting.IndexBkt = textTestBkt + "_description2_index"
+	resp, err = bo.Run(httpClient, bobb.OpIndexSetting, bobb.IndexSettingRequest{IndexSettings: []bobb.IndexSetting{setting}})
+	if err := checkResp(resp, err, "TextSearch - IndexSettingRequest 2"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = bo.Run(httpClient, bobb.OpIndexRequest, bobb.IndexRequest{DataBkt: textTestBkt, IndexBkt: setting.IndexBkt, IndexAll: true})
+	if err := checkResp(resp, err, "TextSearch - IndexRequest"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = bo.Run(httpClient, bobb.OpTextSearch, bobb.TextSearchRequest{IndexBkt: setting.IndexBkt, AnyTerms: []string{"window", "public"}})
+	if err := checkResp(resp, err, "TextSearch - IndexRequest search"); err != nil {
+		t.Fatal(err)
+	}
+	if got := reqIds(bo.JsonToSlice(resp.Recs, data.Request{})); !slices.Equal(got, []string{"r5", "r2", "r4"}) {
+		t.Errorf("TextSearch IndexRequest: expected [r5 r2 r4], got %v", got)
+	}
+}
@@ -9,6 +9,11 @@ type Request interface {
 	Run(*bolt.Tx) (*Response, error) // executes the request
 }
 
+// DBRequest is implemented by requests that are not run in a trans, bobb_server calls RunDB instead of Run.
+type DBRequest interface {
+	RunDB(*bolt.DB) (*Response, error)
+}
+
 type CsvExport interface {
 	CsvHeader(includeJoins bool) []string
 	CsvData(includeJoins bool) []string
@@ -16,13 +21,18 @@ type CsvExport interface {
 
 // FldFormat is used by MergeFlds in rec.go, typically for creating index keys.
 // Strings - padded to right with spaces or truncated as needed.
-// Ints - leading zeros added as needed.
+// Ints - leading zeros added as needed. Negative values are encoded so they sort before positive values.
+// Transform derives the value from the fld, ex. TransformMonth converts "2024-03-15" to "2024-03".
+// Desc encodes the value so the component sorts in descending order. Exact for printable ascii values, other chars are not inverted.
 type FldFormat struct {
 	FldName    string // name of fld in record
 	FldType    string // FldTypeStr or FldTypeInt  ("string" or "int")
 	Length     int    // output length of value
 	UseDefault string // controls value used when fld not found or null in data rec, use constant from codes.go: DefaultAlways, DefaultNever, DefaultIsNull, DefaultNotFound
 	StrOption  string // for string flds, use Str* code (see codes.go) to control conversion, ex. StrLowerCase
+	Desc       bool   // if true, component sorts in descending order, ex. (customer asc, date desc) index
+	Transform  string // optional, for string flds, use Transform* code (see codes.go), ex. TransformDay
+	PrefixLen  int    // used by TransformPrefix, number of chars from start of value
 }
 
 // Response type is returned by all db requests.
@@ -40,6 +50,18 @@ type Response struct {
 	NextSeq []int            // returned by Bkt request with Operation = "nextseq"
 	NextKey string           // next key in bkt after last one returned in Recs
 	Errs    []BobbErr        // errs occuring until req.ErrLimit hit
+	Stats   *ExecStats       `json:",omitempty"` // returned if request Explain option is true, see stats.go
+	Meta    []RecMeta        `json:",omitempty"` // Meta[i] describes Recs[i], returned if request WithMeta option is true
+
+	keyRecs bool // Recs are keys or bkt names, not bolt values, see WriteRawResponse
+}
+
+// RecMeta describes 1 rec of Response.Recs, see Response.Meta.
+// Supported by GetRequest, GetAllRequest, GetByIndexRequest, QryRequest and SearchKeysRequest.
+type RecMeta struct {
+	Key      string // data key
+	IndexKey string `json:",omitempty"` // index key that produced the rec, if read through IndexBkt
+	Size     int    // size in bytes of the stored rec, before joins and projection
 }
 
 type BobbErr struct {